@@ -0,0 +1,174 @@
+package pppool
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidAdaptiveConfig = errors.New("invalid adaptive concurrency config")
+
+const DefaultAdaptiveInterval = time.Second
+
+// AdaptiveAlgorithm is the algorithm the adaptive controller adjusts the capacity with.
+type AdaptiveAlgorithm int
+
+const (
+	// AIMD grows the capacity by Increase while callers are waiting, and multiplies it
+	// by Backoff when the average task latency goes above LatencyThreshold.
+	AIMD AdaptiveAlgorithm = iota
+
+	// Gradient compares the latency of the last interval with its long term average and
+	// shrinks the capacity by that ratio, while callers are waiting it leaves sqrt(capacity)
+	// of headroom to grow into.
+	Gradient
+)
+
+// AdaptiveConfig configures the controller set up by WithAdaptiveConcurrency, zero fields get defaults.
+type AdaptiveConfig struct {
+	Algorithm AdaptiveAlgorithm
+
+	// Min and Max bound the capacity, Max defaults to the size passed to the constructor.
+	Min int
+	Max int
+
+	// Interval is how often the capacity is adjusted, DefaultAdaptiveInterval by default.
+	Interval time.Duration
+
+	// AIMD only, LatencyThreshold is required.
+	LatencyThreshold time.Duration
+	Increase         int     //默认1
+	Backoff          float64 //默认0.9
+
+	// Gradient only.
+	Tolerance  float64 //短期延迟超过长期平均的这个倍数才缩容，默认1.5
+	LongWindow int     //长期平均延迟的窗口，单位是Interval，默认20
+	Smoothing  float64 //每次向目标容量移动的比例，默认0.2
+}
+
+func (c *AdaptiveConfig) validate(size int) error {
+	if c.Min <= 0 {
+		c.Min = 1
+	}
+	if c.Max <= 0 {
+		c.Max = size
+	}
+	if c.Max < c.Min || c.Interval < 0 {
+		return ErrInvalidAdaptiveConfig
+	}
+	if c.Interval == 0 {
+		c.Interval = DefaultAdaptiveInterval
+	}
+	switch c.Algorithm {
+	case AIMD:
+		if c.LatencyThreshold <= 0 || c.Backoff < 0 || c.Backoff >= 1 {
+			return ErrInvalidAdaptiveConfig
+		}
+		if c.Increase <= 0 {
+			c.Increase = 1
+		}
+		if c.Backoff == 0 {
+			c.Backoff = 0.9
+		}
+	case Gradient:
+		if c.Tolerance < 0 || c.Smoothing < 0 || c.Smoothing > 1 {
+			return ErrInvalidAdaptiveConfig
+		}
+		if c.Tolerance == 0 {
+			c.Tolerance = 1.5
+		}
+		if c.LongWindow <= 0 {
+			c.LongWindow = 20
+		}
+		if c.Smoothing == 0 {
+			c.Smoothing = 0.2
+		}
+	default:
+		return ErrInvalidAdaptiveConfig
+	}
+	return nil
+}
+
+// adaptiveController is only accessed by the ticktock goroutine.
+type adaptiveController struct {
+	config AdaptiveConfig
+
+	lastCount uint64
+	lastSum   time.Duration
+
+	limit   float64       //Gradient下未取整的容量
+	longRTT time.Duration //Gradient下的长期平均延迟
+}
+
+func newAdaptiveController(config AdaptiveConfig) *adaptiveController {
+	return &adaptiveController{config: config}
+}
+
+// adjust tunes the capacity from the task latency of the last interval and the number of
+// callers waiting for a worker or tasks waiting in the task queue.
+func (c *adaptiveController) adjust(p *poolCommon) {
+	count, sum := p.execLatency.total()
+	n, d := count-c.lastCount, sum-c.lastSum
+	c.lastCount, c.lastSum = count, sum
+	var latency time.Duration
+	if n > 0 {
+		latency = d / time.Duration(n)
+	}
+	waiting := p.Waiting() + p.Queued()
+
+	capacity := p.Cap()
+	var size int
+	switch c.config.Algorithm {
+	case AIMD:
+		size = c.aimd(capacity, latency, waiting)
+	case Gradient:
+		size = c.gradient(capacity, latency, waiting)
+	}
+	size = min(max(size, c.config.Min), c.config.Max)
+	if size == capacity {
+		return
+	}
+	if err := p.Tune(size); err != nil {
+		return
+	}
+	p.adjustments.Add(1)
+	p.logf("adaptive concurrency: capacity %d -> %d (latency %v, waiting %d)\n", capacity, size, latency, waiting)
+}
+
+func (c *adaptiveController) aimd(capacity int, latency time.Duration, waiting int) int {
+	if latency > c.config.LatencyThreshold {
+		return int(float64(capacity) * c.config.Backoff)
+	}
+	if waiting > 0 {
+		return capacity + c.config.Increase
+	}
+	return capacity
+}
+
+func (c *adaptiveController) gradient(capacity int, latency time.Duration, waiting int) int {
+	if c.limit == 0 || int(math.Round(c.limit)) != capacity { //容量被Tune手动调整过
+		c.limit = float64(capacity)
+	}
+	if latency == 0 { //这段时间没有任务完成，没有样本
+		return capacity
+	}
+	if c.longRTT == 0 {
+		c.longRTT = latency
+	} else {
+		window := time.Duration(c.config.LongWindow)
+		c.longRTT += (latency - c.longRTT) / window
+		//负载下降后长期平均恢复得很慢，让它更快跟上
+		if c.longRTT > 2*latency {
+			c.longRTT = c.longRTT * 95 / 100
+		}
+	}
+
+	gradient := min(max(c.config.Tolerance*float64(c.longRTT)/float64(latency), 0.5), 1)
+	target := c.limit * gradient
+	if waiting > 0 {
+		target += math.Sqrt(c.limit)
+	}
+	c.limit = c.limit*(1-c.config.Smoothing) + target*c.config.Smoothing
+	c.limit = min(max(c.limit, float64(c.config.Min)), float64(c.config.Max))
+	return int(math.Round(c.limit))
+}
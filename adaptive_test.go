@@ -0,0 +1,78 @@
+package pppool
+
+import (
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveConfig(t *testing.T) {
+	_, err := NewPool(10, WithAdaptiveConcurrency(AdaptiveConfig{Algorithm: AIMD}))
+	require.ErrorIs(t, err, ErrInvalidAdaptiveConfig)
+	_, err = NewPool(10, WithPreAlloc(true), WithAdaptiveConcurrency(AdaptiveConfig{Algorithm: Gradient}))
+	require.ErrorIs(t, err, ErrInvalidAdaptiveConfig)
+	_, err = NewPool(-1, WithAdaptiveConcurrency(AdaptiveConfig{Algorithm: Gradient}))
+	require.ErrorIs(t, err, ErrInvalidAdaptiveConfig)
+	_, err = NewPool(10, WithAdaptiveConcurrency(AdaptiveConfig{Algorithm: Gradient, Min: 5, Max: 4}))
+	require.ErrorIs(t, err, ErrInvalidAdaptiveConfig)
+
+	p, err := NewPool(50, WithAdaptiveConcurrency(AdaptiveConfig{Algorithm: Gradient, Min: 2, Max: 20}))
+	require.NoError(t, err)
+	defer p.Release()
+	require.Equal(t, 20, p.Cap())
+	require.Equal(t, 1.5, p.Options().Adaptive.Tolerance)
+}
+
+func TestAdaptiveAIMD(t *testing.T) {
+	c := newAdaptiveController(AdaptiveConfig{Algorithm: AIMD, LatencyThreshold: 10 * time.Millisecond, Increase: 2, Backoff: 0.5})
+	require.Equal(t, 12, c.aimd(10, time.Millisecond, 3))
+	require.Equal(t, 10, c.aimd(10, time.Millisecond, 0))
+	require.Equal(t, 5, c.aimd(10, 20*time.Millisecond, 3))
+}
+
+func TestAdaptiveGradient(t *testing.T) {
+	cfg := AdaptiveConfig{Algorithm: Gradient}
+	require.NoError(t, cfg.validate(100))
+	c := newAdaptiveController(cfg)
+
+	//延迟稳定且有调用者等待时扩容
+	size := 16
+	for i := 0; i < 10; i++ {
+		size = c.gradient(size, 10*time.Millisecond, 5)
+	}
+	require.Greater(t, size, 16)
+
+	//延迟远高于长期平均时缩容
+	grown := size
+	for i := 0; i < 10; i++ {
+		size = c.gradient(size, 100*time.Millisecond, 5)
+	}
+	require.Less(t, size, grown)
+}
+
+func TestAdaptivePool(t *testing.T) {
+	p, err := NewPool(2, WithAdaptiveConcurrency(AdaptiveConfig{
+		Algorithm:        AIMD,
+		Max:              8,
+		Interval:         10 * time.Millisecond,
+		LatencyThreshold: time.Second,
+	}), WithLogger(log.New(io.Discard, "", 0)))
+	require.NoError(t, err)
+	defer p.Release()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.Submit(func() { time.Sleep(20 * time.Millisecond) })
+		}()
+	}
+	require.Eventually(t, func() bool { return p.Cap() == 8 }, 2*time.Second, 5*time.Millisecond)
+	wg.Wait()
+	require.GreaterOrEqual(t, p.Stats().Adjustments, int64(6))
+}
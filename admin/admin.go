@@ -0,0 +1,190 @@
+// Package admin provides an http.Handler to inspect and control pppool pools at runtime.
+//
+// Routes, relative to where the handler is mounted (use http.StripPrefix for a sub path):
+//
+//	GET  /pools                list the registered pools and their stats
+//	GET  /pools/{name}         stats and options of a pool
+//	POST /pools/{name}/tune    change the capacity, with the form value size, 409 if the pool can not be tuned
+//	POST /pools/{name}/pause   reject new submissions
+//	POST /pools/{name}/resume  accept new submissions again
+//	POST /pools/{name}/purge   reap the stale idle workers right away
+//	POST /pools/{name}/release release the pool
+//
+// Besides the pools registered to the handler, every pool in the pppool registry
+// (see pppool.Register) is listed and controllable, the former win on name clashes.
+//
+// POST requests must carry the header "Authorization: Bearer <token>".
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"pppool"
+)
+
+// Pool is the set of pool methods used by the handler, it's implemented by
+// Pool, PoolWithFunc and PoolWithFuncGeneric.
+type Pool interface {
+	Stats() pppool.Stats
+	Options() pppool.Options
+	Tune(size int) error
+	Pause()
+	Resume()
+	IsPaused() bool
+	IsClosed() bool
+	Purge()
+	Release()
+}
+
+type Handler struct {
+	token string
+	mux   *http.ServeMux
+
+	mu    sync.RWMutex
+	pools map[string]Pool
+}
+
+// NewHandler returns a handler whose POST requests are authenticated with token,
+// an empty token rejects all of them.
+func NewHandler(token string) *Handler {
+	h := &Handler{
+		token: token,
+		mux:   http.NewServeMux(),
+		pools: make(map[string]Pool),
+	}
+	h.mux.HandleFunc("GET /pools", h.list)
+	h.mux.HandleFunc("GET /pools/{name}", h.get)
+	h.mux.HandleFunc("POST /pools/{name}/tune", h.auth(h.tune))
+	h.mux.HandleFunc("POST /pools/{name}/pause", h.auth(h.action(Pool.Pause)))
+	h.mux.HandleFunc("POST /pools/{name}/resume", h.auth(h.action(Pool.Resume)))
+	h.mux.HandleFunc("POST /pools/{name}/purge", h.auth(h.action(Pool.Purge)))
+	h.mux.HandleFunc("POST /pools/{name}/release", h.auth(h.action(Pool.Release)))
+	return h
+}
+
+// Register adds a pool under name, registering the same name again replaces the pool.
+func (h *Handler) Register(name string, p Pool) {
+	h.mu.Lock()
+	h.pools[name] = p
+	h.mu.Unlock()
+}
+
+func (h *Handler) Unregister(name string) {
+	h.mu.Lock()
+	delete(h.pools, name)
+	h.mu.Unlock()
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type poolView struct {
+	Name    string          `json:"name"`
+	Paused  bool            `json:"paused"`
+	Closed  bool            `json:"closed"`
+	Stats   pppool.Stats    `json:"stats"`
+	Options *pppool.Options `json:"options,omitempty"`
+}
+
+func newPoolView(name string, p Pool, withOptions bool) poolView {
+	v := poolView{
+		Name:   name,
+		Paused: p.IsPaused(),
+		Closed: p.IsClosed(),
+		Stats:  p.Stats(),
+	}
+	if withOptions {
+		opts := p.Options()
+		v.Options = &opts
+	}
+	return v
+}
+
+func (h *Handler) lookup(w http.ResponseWriter, r *http.Request) (string, Pool, bool) {
+	name := r.PathValue("name")
+	h.mu.RLock()
+	p, ok := h.pools[name]
+	h.mu.RUnlock()
+	if !ok {
+		p, ok = pppool.Lookup(name)
+	}
+	if !ok {
+		http.Error(w, "pool not found", http.StatusNotFound)
+	}
+	return name, p, ok
+}
+
+func (h *Handler) list(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	views := make([]poolView, 0, len(h.pools))
+	for name, p := range h.pools {
+		views = append(views, newPoolView(name, p, false))
+	}
+	pppool.Range(func(name string, p pppool.Instance) bool {
+		if _, ok := h.pools[name]; !ok {
+			views = append(views, newPoolView(name, p, false))
+		}
+		return true
+	})
+	h.mu.RUnlock()
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	writeJSON(w, views)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	if name, p, ok := h.lookup(w, r); ok {
+		writeJSON(w, newPoolView(name, p, true))
+	}
+}
+
+func (h *Handler) tune(w http.ResponseWriter, r *http.Request) {
+	name, p, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	size, err := strconv.Atoi(r.FormValue("size"))
+	if err != nil || size <= 0 {
+		http.Error(w, "size must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	if err := p.Tune(size); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, newPoolView(name, p, false))
+}
+
+func (h *Handler) action(fn func(Pool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if name, p, ok := h.lookup(w, r); ok {
+			fn(p)
+			writeJSON(w, newPoolView(name, p, false))
+		}
+	}
+}
+
+func (h *Handler) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
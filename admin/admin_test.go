@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"pppool"
+)
+
+func do(t *testing.T, h http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	p, err := pppool.NewPool(10, pppool.WithPanicHandler(func(any) {}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	h := NewHandler("secret")
+	h.Register("api", p)
+
+	rec := do(t, h, "GET", "/pools", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var views []poolView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &views))
+	require.Len(t, views, 1)
+	require.Equal(t, "api", views[0].Name)
+	require.EqualValues(t, 10, views[0].Stats.Capacity)
+
+	rec = do(t, h, "GET", "/pools/api", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var view poolView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &view))
+	require.NotNil(t, view.Options)
+	require.Equal(t, pppool.DefaultCleanIntervalTime, view.Options.ExpiryDuration)
+
+	require.Equal(t, http.StatusNotFound, do(t, h, "GET", "/pools/none", "").Code)
+
+	require.Equal(t, http.StatusUnauthorized, do(t, h, "POST", "/pools/api/tune?size=20", "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(t, h, "POST", "/pools/api/tune?size=20", "wrong").Code)
+	require.EqualValues(t, 10, p.Cap())
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pools/api/tune?size=20", "secret").Code)
+	require.EqualValues(t, 20, p.Cap())
+	require.Equal(t, http.StatusBadRequest, do(t, h, "POST", "/pools/api/tune?size=x", "secret").Code)
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pools/api/pause", "secret").Code)
+	require.ErrorIs(t, p.Submit(func() {}), pppool.ErrPoolPaused)
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pools/api/resume", "secret").Code)
+	require.NoError(t, p.Submit(func() {}))
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pools/api/purge", "secret").Code)
+
+	rec = do(t, h, "POST", "/pools/api/release", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, strings.Contains(rec.Body.String(), `"closed":true`))
+	require.True(t, p.IsClosed())
+
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, h, "GET", "/pools/api/release", "secret").Code)
+}
+
+func TestHandlerTuneUnsupported(t *testing.T) {
+	p, err := pppool.NewPool(10, pppool.WithPreAlloc(true))
+	require.NoError(t, err)
+	defer p.Release()
+
+	h := NewHandler("secret")
+	h.Register("prealloc", p)
+	require.Equal(t, http.StatusConflict, do(t, h, "POST", "/pools/prealloc/tune?size=20", "secret").Code)
+	require.EqualValues(t, 10, p.Cap())
+}
+
+func TestHandlerEmptyToken(t *testing.T) {
+	p, err := pppool.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	h := NewHandler("")
+	h.Register("api", p)
+	require.Equal(t, http.StatusUnauthorized, do(t, h, "POST", "/pools/api/pause", "").Code)
+	require.False(t, p.IsPaused())
+}
+
+func TestHandlerRegistry(t *testing.T) {
+	p, err := pppool.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, pppool.Register("admin-registry", p))
+
+	h := NewHandler("secret")
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/pools/admin-registry", "").Code)
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/pools/admin-registry/pause", "secret").Code)
+	require.True(t, p.IsPaused())
+}
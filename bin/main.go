@@ -4,6 +4,7 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"pppool"
+	pppoolexpvar "pppool/expvar"
 	"sync/atomic"
 	"time"
 )
@@ -37,6 +38,8 @@ func main() {
     }()
 	atomic.StoreInt32(&sum, 0)
 	pool, _ := pppool.NewPool(2, pppool.WithPreAlloc(true), pppool.WithDisablePurge(true))
+	// 在 /debug/vars 中查看pool的统计数据
+	pppoolexpvar.Publish("pppool", pool)
 	go test(pool, "1111")
 	go test(pool, "2222")
 
@@ -0,0 +1,230 @@
+package pppool
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CronEntryID int
+
+// CronEntry is a snapshot of a cron entry.
+type CronEntry struct {
+	ID   CronEntryID
+	Spec string
+
+	// Next is the next fire time, zero if the cron is stopped or the schedule has no more fire time.
+	Next time.Time
+
+	// Prev is the last fire time, zero if it has never fired.
+	Prev time.Time
+
+	// Skipped is the number of firings skipped because the previous run was still running.
+	Skipped int
+}
+
+type cronEntry struct {
+	id       CronEntryID
+	spec     string
+	schedule CronSchedule
+	job      func()
+
+	next  time.Time
+	prev  time.Time
+	timer *wheelTimer
+
+	running atomic.Bool
+	skipped atomic.Int64
+}
+
+// Cron fires jobs according to cron expressions, each firing is submitted to the pool
+// by the timing wheel of the pool, so the jobs are bounded by the capacity of the pool.
+type Cron struct {
+	pool *Pool
+
+	loc           *time.Location
+	skipIfRunning bool
+
+	mu      sync.Mutex
+	entries map[CronEntryID]*cronEntry
+	nextID  CronEntryID
+	running bool
+	resets  int64 //Start时scheduler的reset次数
+}
+
+type CronOption func(c *Cron)
+
+// WithCronLocation sets the time zone of the expressions without a CRON_TZ prefix, time.Local by default.
+func WithCronLocation(loc *time.Location) CronOption {
+	return func(c *Cron) {
+		c.loc = loc
+	}
+}
+
+// WithCronSkipIfStillRunning skips a firing if the previous run of the same entry has not finished yet.
+func WithCronSkipIfStillRunning() CronOption {
+	return func(c *Cron) {
+		c.skipIfRunning = true
+	}
+}
+
+func NewCron(p *Pool, options ...CronOption) *Cron {
+	c := &Cron{
+		pool:    p,
+		loc:     time.Local,
+		entries: make(map[CronEntryID]*cronEntry),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// AddFunc adds a job fired according to spec, see ParseCron for the syntax.
+func (c *Cron) AddFunc(spec string, job func()) (CronEntryID, error) {
+	schedule, err := ParseCron(spec, c.loc)
+	if err != nil {
+		return 0, err
+	}
+	return c.AddSchedule(spec, schedule, job), nil
+}
+
+// AddSchedule adds a job fired according to schedule, spec is only used for display.
+func (c *Cron) AddSchedule(spec string, schedule CronSchedule, job func()) CronEntryID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	e := &cronEntry{id: c.nextID, spec: spec, schedule: schedule, job: job}
+	c.entries[e.id] = e
+	if c.running {
+		c.scheduleLocked(e, time.Now())
+	}
+	return e.id
+}
+
+func (c *Cron) Remove(id CronEntryID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[id]; ok {
+		c.unscheduleLocked(e)
+		delete(c.entries, id)
+	}
+}
+
+// Start starts firing the entries. Releasing the pool drops the timers of the cron, so after
+// Reboot call Start again to resume a cron which is still running.
+func (c *Cron) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resets := c.pool.scheduler.resets.Load()
+	if c.running && c.resets == resets {
+		return
+	}
+	c.running = true
+	c.resets = resets
+	now := time.Now()
+	for _, e := range c.entries {
+		c.scheduleLocked(e, now)
+	}
+}
+
+// Stop stops firing new runs. Jobs which have started are not affected, but firings which
+// are due and still waiting for a worker are dropped.
+func (c *Cron) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return
+	}
+	c.running = false
+	for _, e := range c.entries {
+		c.unscheduleLocked(e)
+	}
+}
+
+// Entries returns a snapshot of all entries ordered by their next fire time.
+func (c *Cron) Entries() []CronEntry {
+	c.mu.Lock()
+	entries := make([]CronEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, CronEntry{
+			ID:      e.id,
+			Spec:    e.spec,
+			Next:    e.next,
+			Prev:    e.prev,
+			Skipped: int(e.skipped.Load()),
+		})
+	}
+	c.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Next.IsZero() || entries[j].Next.IsZero() {
+			return !entries[i].Next.IsZero()
+		}
+		return entries[i].Next.Before(entries[j].Next)
+	})
+	return entries
+}
+
+// NextN returns the next n fire times of the entry from now on.
+func (c *Cron) NextN(id CronEntryID, n int) []time.Time {
+	c.mu.Lock()
+	e, ok := c.entries[id]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	times := make([]time.Time, 0, n)
+	for t := time.Now(); len(times) < n; {
+		if t = e.schedule.Next(t); t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+func (c *Cron) scheduleLocked(e *cronEntry, now time.Time) {
+	e.timer = nil
+	if e.next = e.schedule.Next(now); e.next.IsZero() {
+		return
+	}
+	t := &wheelTimer{expiration: e.next, task: func() { c.run(e) }}
+	t.expire = func() bool { return c.expire(e, t) }
+	e.timer = t
+	c.pool.scheduler.wheel.add(t)
+}
+
+func (c *Cron) unscheduleLocked(e *cronEntry) {
+	if e.timer != nil {
+		c.pool.scheduler.wheel.remove(e.timer)
+		e.timer = nil
+	}
+	e.next = time.Time{}
+}
+
+// expire is called when the timer of e expires, before the job is submitted, so the next
+// firing is scheduled even if the pool rejects this one. A timer which has been replaced
+// (e.g. by Stop and Start) does not fire.
+func (c *Cron) expire(e *cronEntry, t *wheelTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e.timer != t {
+		return false
+	}
+	e.prev = e.next
+	c.scheduleLocked(e, time.Now())
+	return true
+}
+
+// run runs on a worker of the pool.
+func (c *Cron) run(e *cronEntry) {
+	if c.skipIfRunning {
+		if !e.running.CompareAndSwap(false, true) {
+			e.skipped.Add(1)
+			return
+		}
+		defer e.running.Store(false)
+	}
+	e.job()
+}
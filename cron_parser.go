@@ -0,0 +1,325 @@
+package pppool
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule describes when a cron entry fires.
+type CronSchedule interface {
+	// Next returns the next fire time strictly after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+type cronBounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	cronSeconds = cronBounds{0, 59, nil}
+	cronMinutes = cronBounds{0, 59, nil}
+	cronHours   = cronBounds{0, 23, nil}
+	cronDom     = cronBounds{1, 31, nil}
+	cronMonths  = cronBounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	//7和0都表示周日
+	cronDow = cronBounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// starBit marks a field written as "*" or "?", which matters when matching days.
+const starBit = 1 << 63
+
+type specSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+
+	loc *time.Location
+}
+
+type everySchedule struct {
+	delay time.Duration
+}
+
+// ParseCron parses a standard cron expression with 5 fields (minute hour dom month dow) or
+// 6 fields (second minute hour dom month dow). Fields support lists, ranges, steps and the
+// names of months and weekdays. The descriptors @yearly, @annually, @monthly, @weekly, @daily,
+// @midnight, @hourly and @every <duration> are supported as well. A "CRON_TZ=<zone> " or
+// "TZ=<zone> " prefix evaluates the expression in that time zone, otherwise loc is used.
+func ParseCron(spec string, loc *time.Location) (CronSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.Index(spec, " ")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid cron spec %q: missing fields after time zone", spec)
+		}
+		var err error
+		if loc, err = time.LoadLocation(spec[strings.Index(spec, "=")+1 : i]); err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		return parseCronDescriptor(spec, loc)
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 or 6 fields, found %d", spec, len(fields))
+	}
+
+	s := &specSchedule{loc: loc}
+	for i, f := range []struct {
+		bits   *uint64
+		bounds cronBounds
+	}{
+		{&s.second, cronSeconds},
+		{&s.minute, cronMinutes},
+		{&s.hour, cronHours},
+		{&s.dom, cronDom},
+		{&s.month, cronMonths},
+		{&s.dow, cronDow},
+	} {
+		bits, err := parseCronField(fields[i], f.bounds)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		*f.bits = bits
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+func parseCronDescriptor(spec string, loc *time.Location) (CronSchedule, error) {
+	all := func(b cronBounds) uint64 {
+		return cronBits(b.min, b.max, 1) | starBit
+	}
+	s := &specSchedule{second: 1, minute: 1, hour: 1, dom: 1, month: 1, dow: 1, loc: loc}
+	switch spec {
+	case "@yearly", "@annually":
+		s.month, s.dom, s.dow = 1<<1, 1<<1, all(cronDow)
+	case "@monthly":
+		s.month, s.dom, s.dow = all(cronMonths), 1<<1, all(cronDow)
+	case "@weekly":
+		s.month, s.dom, s.dow = all(cronMonths), all(cronDom), 1<<0
+	case "@daily", "@midnight":
+		s.month, s.dom, s.dow = all(cronMonths), all(cronDom), all(cronDow)
+	case "@hourly":
+		s.month, s.dom, s.dow, s.hour = all(cronMonths), all(cronDom), all(cronDow), all(cronHours)
+	default:
+		if every, ok := strings.CutPrefix(spec, "@every "); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(every))
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+			}
+			if d < time.Second {
+				return nil, fmt.Errorf("invalid cron spec %q: interval must be at least 1s", spec)
+			}
+			return everySchedule{delay: d}, nil
+		}
+		return nil, fmt.Errorf("invalid cron spec %q: unknown descriptor", spec)
+	}
+	return s, nil
+}
+
+func parseCronField(field string, b cronBounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		r, err := parseCronRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+// parseCronRange parses one of "*", "?", "a", "a-b", "*/n", "a/n" and "a-b/n".
+func parseCronRange(expr string, b cronBounds) (uint64, error) {
+	var (
+		start, end, step uint
+		extra            uint64
+		err              error
+	)
+	rangeAndStep := strings.Split(expr, "/")
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) != 1 {
+			return 0, fmt.Errorf("invalid range %q", expr)
+		}
+		start, end, extra = b.min, b.max, starBit
+	} else {
+		if start, err = parseCronValue(lowAndHigh[0], b); err != nil {
+			return 0, err
+		}
+		switch len(lowAndHigh) {
+		case 1:
+			end = start
+		case 2:
+			if end, err = parseCronValue(lowAndHigh[1], b); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, fmt.Errorf("invalid range %q", expr)
+		}
+	}
+
+	switch len(rangeAndStep) {
+	case 1:
+		step = 1
+	case 2:
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 0)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid step %q", expr)
+		}
+		step = uint(n)
+		// "a/n" means from a to the max value
+		if len(lowAndHigh) == 1 && extra == 0 {
+			end = b.max
+		}
+		if step > 1 {
+			extra = 0
+		}
+	default:
+		return 0, fmt.Errorf("invalid step %q", expr)
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, fmt.Errorf("range %q out of bounds [%d, %d]", expr, b.min, b.max)
+	}
+	return cronBits(start, end, step) | extra, nil
+}
+
+func parseCronValue(s string, b cronBounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return uint(n), nil
+}
+
+func cronBits(min, max, step uint) uint64 {
+	if step == 1 {
+		return ^(math.MaxUint64 << (max + 1)) & (math.MaxUint64 << min)
+	}
+	var bits uint64
+	for i := min; i <= max; i += step {
+		bits |= 1 << i
+	}
+	return bits
+}
+
+func (s *specSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	//从下一秒开始找
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		//夏令时切换时午夜可能不存在，修正回当天的0点
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches follows the cron convention: if both dom and dow are restricted,
+// a day matching either of them is fine.
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow > 0
+	if s.dom&starBit > 0 || s.dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.delay - time.Duration(t.Nanosecond()))
+}
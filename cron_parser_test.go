@@ -0,0 +1,76 @@
+package pppool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronNext(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{"* * * * *", "2024-01-01T10:00:30Z", "2024-01-01T10:01:00Z"},
+		{"*/15 * * * * *", "2024-01-01T10:00:31Z", "2024-01-01T10:00:45Z"},
+		{"30 9 * * mon-fri", "2024-06-07T10:00:00Z", "2024-06-10T09:30:00Z"},
+		{"0 0 1,15 * *", "2024-02-02T00:00:00Z", "2024-02-15T00:00:00Z"},
+		{"0 12 * JAN,jul *", "2024-02-01T00:00:00Z", "2024-07-01T12:00:00Z"},
+		{"0 0 29 2 *", "2023-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{"0 0 * * 7", "2024-06-03T00:00:00Z", "2024-06-09T00:00:00Z"},
+		{"0 10-20/5 * * *", "2024-01-01T15:00:00Z", "2024-01-01T20:00:00Z"},
+		{"5/20 * * * *", "2024-01-01T10:06:00Z", "2024-01-01T10:25:00Z"},
+		//dom和dow都有限制时满足其一即可
+		{"0 0 13 * fri", "2024-09-01T00:00:00Z", "2024-09-06T00:00:00Z"},
+		{"@daily", "2024-01-01T10:00:00Z", "2024-01-02T00:00:00Z"},
+		{"@hourly", "2024-01-01T10:00:00Z", "2024-01-01T11:00:00Z"},
+		{"@weekly", "2024-06-03T00:00:00Z", "2024-06-09T00:00:00Z"},
+		{"@monthly", "2024-06-03T00:00:00Z", "2024-07-01T00:00:00Z"},
+		{"@yearly", "2024-06-03T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"@every 90s", "2024-01-01T10:00:00Z", "2024-01-01T10:01:30Z"},
+		{"CRON_TZ=Asia/Shanghai 0 8 * * *", "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.spec, utc)
+		require.NoError(t, err, tt.spec)
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		next, _ := time.Parse(time.RFC3339, tt.next)
+		require.True(t, next.Equal(s.Next(from)), "%s: expected %v, got %v", tt.spec, next, s.Next(from))
+	}
+}
+
+func TestParseCronLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	s, err := ParseCron("0 9 * * *", loc)
+	require.NoError(t, err)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.True(t, time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC).Equal(s.Next(from)))
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-2-3 * * * *",
+		"@every 1ms",
+		"@every x",
+		"@never",
+		"CRON_TZ=Nowhere/Nothing * * * * *",
+	} {
+		_, err := ParseCron(spec, time.UTC)
+		require.Error(t, err, spec)
+	}
+}
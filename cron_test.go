@@ -0,0 +1,114 @@
+package pppool
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	block := make(chan struct{})
+	defer close(block)
+	var fired, blocked int32
+	c := NewCron(p, WithCronSkipIfStillRunning())
+	id, err := c.AddFunc("* * * * * *", func() { atomic.AddInt32(&fired, 1) })
+	require.NoError(t, err)
+	slow, err := c.AddFunc("@every 1s", func() {
+		atomic.AddInt32(&blocked, 1)
+		<-block
+	})
+	require.NoError(t, err)
+
+	times := c.NextN(id, 3)
+	require.Len(t, times, 3)
+	require.Equal(t, time.Second, times[1].Sub(times[0]))
+	require.Equal(t, time.Second, times[2].Sub(times[1]))
+
+	c.Start()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&fired) >= 2 }, 5*time.Second, 10*time.Millisecond)
+
+	skipped := func() int {
+		for _, e := range c.Entries() {
+			if e.ID == slow {
+				return e.Skipped
+			}
+		}
+		return 0
+	}
+	require.Eventually(t, func() bool { return skipped() > 0 }, 5*time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt32(&blocked), "overlapping runs should be skipped")
+
+	entries := c.Entries()
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		require.False(t, e.Next.IsZero())
+		require.False(t, e.Prev.IsZero())
+	}
+
+	c.Stop()
+	n := atomic.LoadInt32(&fired)
+	time.Sleep(1500 * time.Millisecond)
+	require.EqualValues(t, n, atomic.LoadInt32(&fired))
+	require.EqualValues(t, 1, atomic.LoadInt32(&blocked))
+	for _, e := range c.Entries() {
+		require.True(t, e.Next.IsZero())
+	}
+
+	c.Remove(id)
+	require.Len(t, c.Entries(), 1)
+	require.Nil(t, c.NextN(id, 1))
+}
+
+type testInterval time.Duration
+
+func (d testInterval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+func TestCronRestartAfterReboot(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	var fired atomic.Int32
+	c := NewCron(p)
+	c.AddSchedule("every 20ms", testInterval(20*time.Millisecond), func() { fired.Add(1) })
+	c.Start()
+	require.Eventually(t, func() bool { return fired.Load() > 0 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	require.NoError(t, p.Reboot())
+	n := fired.Load()
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, n, fired.Load(), "timers are dropped by Release")
+
+	c.Start()
+	require.Eventually(t, func() bool { return fired.Load() > n }, time.Second, 5*time.Millisecond)
+	c.Stop()
+}
+
+func TestCronRejectedFiring(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1, WithNonblocking(true), WithLogger(log.New(io.Discard, "", 0)))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Submit(func() { <-block }))
+
+	var fired atomic.Int32
+	c := NewCron(p)
+	c.AddSchedule("every 20ms", testInterval(20*time.Millisecond), func() { fired.Add(1) })
+	c.Start()
+	defer c.Stop()
+
+	require.Eventually(t, func() bool { return p.Stats().Rejected > 0 }, time.Second, 5*time.Millisecond)
+	close(block)
+	require.Eventually(t, func() bool { return fired.Load() > 0 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,23 @@
+// Package expvar publishes the statistics of pppool pools through the standard expvar
+// package. Importing it registers /debug/vars on http.DefaultServeMux, like importing
+// expvar itself does, so it lives apart from pppool for programs which do not want that.
+package expvar
+
+import (
+	"expvar"
+
+	"pppool"
+)
+
+// Source is anything that reports pool statistics, i.e. Pool, PoolWithFunc and PoolWithFuncGeneric.
+type Source interface {
+	Stats() pppool.Stats
+}
+
+// Publish publishes the live Stats of p as an expvar.Func under name, so that they
+// show up in /debug/vars. Like expvar.Publish, it panics if name is already taken.
+func Publish(name string, p Source) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return p.Stats()
+	}))
+}
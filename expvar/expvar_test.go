@@ -0,0 +1,34 @@
+package expvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"pppool"
+)
+
+var seq atomic.Int32 //expvar的名字不能重复发布，-count>1时每次用新的名字
+
+func TestPublish(t *testing.T) {
+	p, err := pppool.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	name := "pppool_test_pool_" + strconv.Itoa(int(seq.Add(1)))
+	Publish(name, p)
+	v := expvar.Get(name)
+	require.NotNil(t, v)
+
+	var s pppool.Stats
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &s))
+	require.EqualValues(t, 10, s.Capacity)
+
+	require.NoError(t, p.Submit(func() {}))
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &s))
+	require.EqualValues(t, 1, s.Submitted)
+}
@@ -0,0 +1,74 @@
+package pppool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by Future.Get when the task panicked, it carries the
+// recovered value and the stack of the panicking goroutine.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v\n%s", e.Value, e.Stack)
+}
+
+type Future[T any] struct {
+	done chan struct{}
+
+	val T
+	err error
+}
+
+// SubmitFunc submits fn to p and returns a Future holding its result. If the task can
+// not be submitted, the Future is completed with the error returned by Submit, and with
+// ErrPoolOverload if it's dropped by DiscardPolicy or DiscardOldestPolicy.
+func SubmitFunc[T any](p *Pool, fn func() (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	err := p.submit(context.Background(), func() {
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+			close(f.done)
+		}()
+		f.val, f.err = fn()
+	}, func(err error) {
+		f.err = err
+		close(f.done)
+	})
+	if err != nil {
+		f.err = err
+		close(f.done)
+	}
+	return f
+}
+
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get blocks until the task finishes or ctx is done.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// TryGet returns the result without blocking, ok is false if the task has not finished yet.
+func (f *Future[T]) TryGet() (val T, ok bool, err error) {
+	select {
+	case <-f.done:
+		return f.val, true, f.err
+	default:
+		return val, false, nil
+	}
+}
@@ -0,0 +1,109 @@
+package pppool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitFunc(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	f := SubmitFunc(p, func() (int, error) {
+		return 42, nil
+	})
+	v, err := f.Get(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 42, v)
+
+	errTask := errors.New("task failed")
+	f = SubmitFunc(p, func() (int, error) {
+		return 0, errTask
+	})
+	<-f.Done()
+	_, ok, err := f.TryGet()
+	require.True(t, ok)
+	require.ErrorIs(t, err, errTask)
+}
+
+func TestSubmitFuncPanic(t *testing.T) {
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+
+	f := SubmitFunc(p, func() (string, error) {
+		panic("boom")
+	})
+	_, err = f.Get(context.Background())
+	var pe *PanicError
+	require.ErrorAs(t, err, &pe)
+	require.Equal(t, "boom", pe.Value)
+	require.NotEmpty(t, pe.Stack)
+}
+
+func TestSubmitFuncGetContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+
+	f := SubmitFunc(p, func() (int, error) {
+		<-block
+		return 1, nil
+	})
+	_, ok, _ := f.TryGet()
+	require.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = f.Get(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSubmitFuncClosedPool(t *testing.T) {
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	p.Release()
+
+	_, err = SubmitFunc(p, func() (int, error) { return 1, nil }).Get(context.Background())
+	require.ErrorIs(t, err, ErrorPoolClosed)
+}
+
+func TestSubmitFuncDiscarded(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1, WithNonblocking(true), WithRejectionPolicy(DiscardPolicy))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Submit(func() { <-block }))
+	_, err = SubmitFunc(p, func() (int, error) { return 1, nil }).Get(context.Background())
+	require.ErrorIs(t, err, ErrPoolOverload)
+	close(block)
+}
+
+func TestSubmitFuncDiscardOldest(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1, WithTaskQueue(1), WithNonblocking(true), WithRejectionPolicy(DiscardOldestPolicy))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Submit(func() { <-block }))
+	oldest := SubmitFunc(p, func() (int, error) { return 1, nil })
+	newest := SubmitFunc(p, func() (int, error) { return 2, nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = oldest.Get(ctx)
+	require.ErrorIs(t, err, ErrPoolOverload)
+
+	close(block)
+	v, err := newest.Get(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, v)
+}
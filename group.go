@@ -0,0 +1,91 @@
+package pppool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Group is a collection of tasks running on the workers of a shared Pool, it works like
+// errgroup.Group: the first error cancels the group context and is returned by Wait.
+type Group struct {
+	pool *Pool
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+func (p *Pool) NewGroup(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{pool: p, ctx: ctx, cancel: cancel}
+}
+
+// SetLimit limits the number of tasks of this group running at the same time,
+// a negative n means no limit. The limit must not change while tasks are active.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("pppool: modify group limit while %v tasks are still active", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs f on a worker of the pool, blocking while the group limit is reached or the
+// pool has no available worker. Once the group context is done no new task is started,
+// a task that can not be submitted or is dropped by the rejection policy fails the group
+// with the submission error or ErrPoolOverload.
+func (g *Group) Go(f func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	err := g.pool.submit(g.ctx, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				g.setErr(&PanicError{Value: r, Stack: debug.Stack()})
+			}
+			g.done()
+		}()
+		if err := f(g.ctx); err != nil {
+			g.setErr(err)
+		}
+	}, func(err error) {
+		g.setErr(err)
+		g.done()
+	})
+	if err != nil {
+		g.setErr(err)
+		g.done()
+	}
+}
+
+// Wait blocks until all tasks started by Go have returned, then returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(g.err)
+	return g.err
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+func (g *Group) setErr(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
@@ -0,0 +1,109 @@
+package pppool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupWait(t *testing.T) {
+	p, err := NewPool(4)
+	require.NoError(t, err)
+	defer p.Release()
+
+	var sum int32
+	g := p.NewGroup(context.Background())
+	for i := 0; i < 100; i++ {
+		g.Go(func(context.Context) error {
+			atomic.AddInt32(&sum, 1)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+	require.EqualValues(t, 100, atomic.LoadInt32(&sum))
+}
+
+func TestGroupFirstErrorCancels(t *testing.T) {
+	p, err := NewPool(4)
+	require.NoError(t, err)
+	defer p.Release()
+
+	errFirst := errors.New("first")
+	g := p.NewGroup(context.Background())
+	g.Go(func(context.Context) error {
+		return errFirst
+	})
+	g.Go(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
+			return nil
+		}
+	})
+	require.ErrorIs(t, g.Wait(), errFirst)
+}
+
+func TestGroupSetLimit(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	var running, maxRunning int32
+	g := p.NewGroup(context.Background())
+	g.SetLimit(2)
+	for i := 0; i < 20; i++ {
+		g.Go(func(context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+	require.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+}
+
+func TestGroupPanic(t *testing.T) {
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+
+	g := p.NewGroup(context.Background())
+	g.Go(func(context.Context) error {
+		panic("boom")
+	})
+	var pe *PanicError
+	require.ErrorAs(t, g.Wait(), &pe)
+}
+
+func TestGroupDiscarded(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1, WithNonblocking(true), WithRejectionPolicy(DiscardPolicy))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Submit(func() { <-block }))
+
+	g := p.NewGroup(context.Background())
+	g.Go(func(context.Context) error { return nil })
+
+	done := make(chan error, 1)
+	go func() { done <- g.Wait() }()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, ErrPoolOverload)
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after the task was discarded")
+	}
+	close(block)
+}
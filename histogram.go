@@ -0,0 +1,106 @@
+package pppool
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	histogramBuckets = 32
+	// histogramBase is the upper bound of the first bucket, bucket i is bounded by histogramBase<<i,
+	// which covers 1µs to about 35 minutes, slower observations go to the overflow bucket.
+	histogramBase = time.Microsecond
+)
+
+// histogram is a lock-free latency histogram with fixed, exponentially spaced buckets.
+type histogram struct {
+	counts [histogramBuckets + 1]atomic.Uint64 //最后一个是溢出桶
+	sum    atomic.Int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := 0
+	if d > histogramBase {
+		//最小的i使得 d <= histogramBase<<i
+		i = bits.Len64(uint64((d - 1) / histogramBase))
+		if i > histogramBuckets {
+			i = histogramBuckets
+		}
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// total returns the number of observations and their sum.
+func (h *histogram) total() (count uint64, sum time.Duration) {
+	for i := range h.counts {
+		count += h.counts[i].Load()
+	}
+	return count, time.Duration(h.sum.Load())
+}
+
+type HistogramBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket, zero for the overflow bucket.
+	UpperBound time.Duration `json:"upper_bound"`
+	Count      uint64        `json:"count"`
+}
+
+type HistogramSnapshot struct {
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+
+	// Buckets holds the non-cumulative count of every bucket, the last one is the overflow bucket.
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Sum:     time.Duration(h.sum.Load()),
+		Buckets: make([]HistogramBucket, len(h.counts)),
+	}
+	for i := range h.counts {
+		n := h.counts[i].Load()
+		s.Count += n
+		s.Buckets[i].Count = n
+		if i < histogramBuckets {
+			s.Buckets[i].UpperBound = histogramBase << i
+		}
+	}
+	s.P50 = s.Quantile(0.5)
+	s.P90 = s.Quantile(0.9)
+	s.P99 = s.Quantile(0.99)
+	return s
+}
+
+// Quantile estimates the q-quantile by interpolating linearly inside the bucket it falls in.
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := q * float64(s.Count)
+	var cum uint64
+	for i, b := range s.Buckets {
+		if b.Count == 0 || float64(cum+b.Count) < rank {
+			cum += b.Count
+			continue
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = s.Buckets[i-1].UpperBound
+		}
+		if b.UpperBound == 0 { //溢出桶没有上界
+			return lower
+		}
+		frac := (rank - float64(cum)) / float64(b.Count)
+		return lower + time.Duration(frac*float64(b.UpperBound-lower))
+	}
+	return s.Buckets[len(s.Buckets)-2].UpperBound
+}
@@ -0,0 +1,53 @@
+package pppool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	var h histogram
+	h.observe(0)
+	h.observe(time.Microsecond)
+	h.observe(1500 * time.Nanosecond)
+	h.observe(4 * time.Microsecond)
+	h.observe(5 * time.Microsecond)
+	h.observe(time.Hour)
+
+	s := h.snapshot()
+	require.EqualValues(t, 6, s.Count)
+	require.Len(t, s.Buckets, histogramBuckets+1)
+	require.EqualValues(t, 2, s.Buckets[0].Count)
+	require.EqualValues(t, 1, s.Buckets[1].Count)
+	require.EqualValues(t, 1, s.Buckets[2].Count)
+	require.EqualValues(t, 1, s.Buckets[3].Count)
+	require.EqualValues(t, 1, s.Buckets[histogramBuckets].Count)
+	require.EqualValues(t, 8*time.Microsecond, s.Buckets[3].UpperBound)
+	require.EqualValues(t, 0, s.Buckets[histogramBuckets].UpperBound)
+	require.EqualValues(t, time.Hour+11500*time.Nanosecond, s.Sum)
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	require.EqualValues(t, 0, h.snapshot().P50)
+
+	for i := 0; i < 100; i++ {
+		h.observe(3 * time.Millisecond)
+	}
+	s := h.snapshot()
+	//3ms落在(2.048ms, 4.096ms]这个桶里
+	for _, q := range []time.Duration{s.P50, s.P90, s.P99} {
+		require.Greater(t, q, 2048*time.Microsecond)
+		require.LessOrEqual(t, q, 4096*time.Microsecond)
+	}
+	require.Less(t, s.P50, s.P99)
+
+	for i := 0; i < 900; i++ {
+		h.observe(10 * time.Microsecond)
+	}
+	s = h.snapshot()
+	require.LessOrEqual(t, s.P50, 16*time.Microsecond)
+	require.Greater(t, s.P99, 2048*time.Microsecond)
+}
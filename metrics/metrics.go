@@ -0,0 +1,160 @@
+// Package metrics exports the statistics of pppool pools in the Prometheus text exposition
+// format, it only depends on the standard library.
+package metrics
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"pppool"
+)
+
+// Source is anything that reports pool statistics, i.e. Pool, PoolWithFunc and PoolWithFuncGeneric.
+type Source interface {
+	Stats() pppool.Stats
+}
+
+// Handler is an http.Handler serving the metrics of the pools registered to it and of those
+// in the pppool registry (see pppool.Register), every sample carries a pool label with the
+// registered name. A pool registered to the handler shadows a registry pool with the same name.
+type Handler struct {
+	mu    sync.RWMutex
+	pools map[string]Source
+}
+
+func NewHandler() *Handler {
+	return &Handler{pools: make(map[string]Source)}
+}
+
+// Register adds a pool under name, registering the same name again replaces the pool.
+func (h *Handler) Register(name string, p Source) {
+	h.mu.Lock()
+	h.pools[name] = p
+	h.mu.Unlock()
+}
+
+func (h *Handler) Unregister(name string) {
+	h.mu.Lock()
+	delete(h.pools, name)
+	h.mu.Unlock()
+}
+
+type namedStats struct {
+	name  string
+	stats pppool.Stats
+}
+
+func (h *Handler) snapshot() []namedStats {
+	h.mu.RLock()
+	all := make([]namedStats, 0, len(h.pools))
+	for name, p := range h.pools {
+		all = append(all, namedStats{name: name, stats: p.Stats()})
+	}
+	pppool.Range(func(name string, p pppool.Instance) bool {
+		if _, ok := h.pools[name]; !ok {
+			all = append(all, namedStats{name: name, stats: p.Stats()})
+		}
+		return true
+	})
+	h.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+	return all
+}
+
+type metric struct {
+	name  string
+	help  string
+	typ   string
+	value func(s *pppool.Stats) float64
+}
+
+var metricsList = []metric{
+	{"pppool_capacity", "Capacity of the pool, -1 means unlimited.", "gauge", func(s *pppool.Stats) float64 { return float64(s.Capacity) }},
+	{"pppool_running_workers", "Number of running workers.", "gauge", func(s *pppool.Stats) float64 { return float64(s.Running) }},
+	{"pppool_idle_workers", "Number of idle workers.", "gauge", func(s *pppool.Stats) float64 { return float64(s.Idle) }},
+	{"pppool_waiting_callers", "Number of callers blocked waiting for a worker.", "gauge", func(s *pppool.Stats) float64 { return float64(s.Waiting) }},
+	{"pppool_queued_tasks", "Number of tasks in the task queue.", "gauge", func(s *pppool.Stats) float64 { return float64(s.Queued) }},
+	{"pppool_tasks_submitted_total", "Total number of tasks accepted by the pool.", "counter", func(s *pppool.Stats) float64 { return float64(s.Submitted) }},
+	{"pppool_tasks_completed_total", "Total number of tasks completed.", "counter", func(s *pppool.Stats) float64 { return float64(s.Completed) }},
+	{"pppool_tasks_panicked_total", "Total number of tasks which panicked.", "counter", func(s *pppool.Stats) float64 { return float64(s.Panicked) }},
+	{"pppool_tasks_rejected_total", "Total number of times the pool was overloaded.", "counter", func(s *pppool.Stats) float64 { return float64(s.Rejected) }},
+	{"pppool_tasks_discarded_total", "Total number of tasks dropped by the rejection policy.", "counter", func(s *pppool.Stats) float64 { return float64(s.Discarded) }},
+	{"pppool_tasks_abandoned_total", "Total number of tasks abandoned after their timeout.", "counter", func(s *pppool.Stats) float64 { return float64(s.Abandoned) }},
+	{"pppool_workers_spawned_total", "Total number of worker goroutines started.", "counter", func(s *pppool.Stats) float64 { return float64(s.Spawned) }},
+	{"pppool_workers_purged_total", "Total number of idle workers purged.", "counter", func(s *pppool.Stats) float64 { return float64(s.Purged) }},
+	{"pppool_workers_retired_total", "Total number of workers retired after their max tasks or max age.", "counter", func(s *pppool.Stats) float64 { return float64(s.Retired) }},
+	{"pppool_capacity_adjustments_total", "Total number of capacity changes made by the adaptive controller.", "counter", func(s *pppool.Stats) float64 { return float64(s.Adjustments) }},
+	{"pppool_wait_seconds_total", "Cumulative time callers spent blocked waiting for a worker.", "counter", func(s *pppool.Stats) float64 { return s.WaitTime.Seconds() }},
+}
+
+var histogramsList = []struct {
+	name  string
+	help  string
+	value func(s *pppool.Stats) *pppool.HistogramSnapshot
+}{
+	{"pppool_task_wait_seconds", "Time from submission to the start of a task.", func(s *pppool.Stats) *pppool.HistogramSnapshot { return &s.WaitLatency }},
+	{"pppool_task_duration_seconds", "Running time of tasks.", func(s *pppool.Stats) *pppool.HistogramSnapshot { return &s.ExecLatency }},
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	all := h.snapshot()
+	for _, m := range metricsList {
+		writeHeader(bw, m.name, m.help, m.typ)
+		for i := range all {
+			writeSample(bw, m.name, all[i].name, "", m.value(&all[i].stats))
+		}
+	}
+	for _, m := range histogramsList {
+		writeHeader(bw, m.name, m.help, "histogram")
+		for i := range all {
+			hs := m.value(&all[i].stats)
+			var cum uint64
+			for _, b := range hs.Buckets {
+				cum += b.Count
+				le := "+Inf"
+				if b.UpperBound != 0 {
+					le = formatFloat(b.UpperBound.Seconds())
+				}
+				writeSample(bw, m.name+"_bucket", all[i].name, le, float64(cum))
+			}
+			writeSample(bw, m.name+"_sum", all[i].name, "", hs.Sum.Seconds())
+			writeSample(bw, m.name+"_count", all[i].name, "", float64(hs.Count))
+		}
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeSample(w *bufio.Writer, name, pool, le string, v float64) {
+	w.WriteString(name)
+	w.WriteString(`{pool="`)
+	w.WriteString(escapeLabel(pool))
+	if le != "" {
+		w.WriteString(`",le="`)
+		w.WriteString(le)
+	}
+	w.WriteString(`"} `)
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"pppool"
+)
+
+func TestHandler(t *testing.T) {
+	p1, err := pppool.NewPool(10)
+	require.NoError(t, err)
+	defer p1.Release()
+	p2, err := pppool.NewPoolWithFunc(5, func(any) {})
+	require.NoError(t, err)
+	defer p2.Release()
+
+	done := make(chan struct{})
+	require.NoError(t, p1.Submit(func() { close(done) }))
+	<-done
+	require.Eventually(t, func() bool { return p1.Stats().Completed == 1 }, time.Second, time.Millisecond)
+
+	h := NewHandler()
+	h.Register("api", p1)
+	h.Register(`batch"jobs`, p2)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, line := range []string{
+		"# TYPE pppool_capacity gauge",
+		`pppool_capacity{pool="api"} 10`,
+		`pppool_capacity{pool="batch\"jobs"} 5`,
+		"# TYPE pppool_tasks_completed_total counter",
+		`pppool_tasks_submitted_total{pool="api"} 1`,
+		`pppool_tasks_completed_total{pool="api"} 1`,
+		"# TYPE pppool_task_duration_seconds histogram",
+		`pppool_task_duration_seconds_bucket{pool="api",le="1e-06"}`,
+		`pppool_task_duration_seconds_bucket{pool="api",le="+Inf"} 1`,
+		`pppool_task_duration_seconds_count{pool="api"} 1`,
+		`pppool_task_wait_seconds_count{pool="batch\"jobs"} 0`,
+	} {
+		require.Contains(t, out, line)
+	}
+	require.Equal(t, 1, strings.Count(out, "# TYPE pppool_capacity gauge"))
+
+	h.Unregister("api")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.NotContains(t, rec.Body.String(), `pool="api"`)
+}
+
+func TestHandlerRegistry(t *testing.T) {
+	p, err := pppool.NewPool(5)
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, pppool.Register("metrics-registry", p))
+
+	rec := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Contains(t, rec.Body.String(), `pppool_capacity{pool="metrics-registry"} 5`)
+}
@@ -21,11 +21,42 @@ type Options struct {
 
 	Nonblocking bool
 
-	PanicHandler func(any)
+	PanicHandler func(any) `json:"-"`
 
-	Logger Logger
+	Logger Logger `json:"-"`
 
 	DisablePurge bool
+
+	RejectionPolicy RejectionPolicy
+
+	RejectionHandler func(task func()) `json:"-"`
+
+	TaskQueueSize int
+
+	PriorityAging time.Duration
+
+	TaskTimeoutGrace time.Duration
+
+	OnTaskTimeout func(timeout time.Duration) `json:"-"`
+
+	//worker协程启动和退出时在该协程上调用
+	OnWorkerStart func() `json:"-"`
+	OnWorkerExit  func() `json:"-"`
+
+	//每个任务执行前后在worker协程上调用
+	BeforeTask func()                                      `json:"-"`
+	AfterTask  func(duration time.Duration, panicked bool) `json:"-"`
+
+	WorkerStateInit  func() any      `json:"-"`
+	WorkerStateClose func(state any) `json:"-"`
+
+	MinIdle int
+
+	//worker执行这么多任务或者存活这么久之后退出，由新的worker代替，0表示不限制
+	WorkerMaxTasks int
+	WorkerMaxAge   time.Duration
+
+	Adaptive *AdaptiveConfig
 }
 
 func WithOptions(options Options) Option {
@@ -58,6 +89,8 @@ func WithNonblocking(nonblocking bool) Option {
 	}
 }
 
+// WithPanicHandler sets the handler of panics in tasks and worker hooks, they are logged
+// with their stack if it's not set.
 func WithPanicHandler(panicHandler func(any)) Option {
 	return func(opts *Options) {
 		opts.PanicHandler = panicHandler
@@ -75,3 +108,115 @@ func WithDisablePurge(disable bool) Option {
 		opts.DisablePurge = disable
 	}
 }
+
+func WithRejectionPolicy(policy RejectionPolicy) Option {
+	return func(opts *Options) {
+		opts.RejectionPolicy = policy
+	}
+}
+
+// WithRejectionHandler sets up CallbackPolicy with the given handler.
+func WithRejectionHandler(handler func(task func())) Option {
+	return func(opts *Options) {
+		opts.RejectionPolicy = CallbackPolicy
+		opts.RejectionHandler = handler
+	}
+}
+
+// WithTaskQueue sets up a bounded task queue of size n, which lets Submit return as soon as the task
+// is queued rather than waiting for an available worker.
+func WithTaskQueue(n int) Option {
+	return func(opts *Options) {
+		opts.TaskQueueSize = n
+	}
+}
+
+// WithPriorityAging raises the priority of a blocked caller by one for every d it has waited.
+func WithPriorityAging(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.PriorityAging = d
+	}
+}
+
+func WithTaskTimeoutGrace(grace time.Duration) Option {
+	return func(opts *Options) {
+		opts.TaskTimeoutGrace = grace
+	}
+}
+
+func WithOnTaskTimeout(onTaskTimeout func(timeout time.Duration)) Option {
+	return func(opts *Options) {
+		opts.OnTaskTimeout = onTaskTimeout
+	}
+}
+
+// WithOnWorkerStart sets a hook called on every worker goroutine right after it starts,
+// e.g. to set pprof labels.
+func WithOnWorkerStart(onWorkerStart func()) Option {
+	return func(opts *Options) {
+		opts.OnWorkerStart = onWorkerStart
+	}
+}
+
+// WithOnWorkerExit sets a hook called on every worker goroutine right before it exits.
+func WithOnWorkerExit(onWorkerExit func()) Option {
+	return func(opts *Options) {
+		opts.OnWorkerExit = onWorkerExit
+	}
+}
+
+func WithBeforeTask(beforeTask func()) Option {
+	return func(opts *Options) {
+		opts.BeforeTask = beforeTask
+	}
+}
+
+// WithAfterTask sets a hook called after every task with its running time, panicked reports
+// whether the task panicked, in which case the hook runs before PanicHandler.
+func WithAfterTask(afterTask func(duration time.Duration, panicked bool)) Option {
+	return func(opts *Options) {
+		opts.AfterTask = afterTask
+	}
+}
+
+// WithWorkerState gives every worker of a Pool its own state for SubmitWithState, init is
+// called on the worker goroutine by its first such task and close (which may be nil) when
+// the worker is purged or finished.
+func WithWorkerState(init func() any, close func(state any)) Option {
+	return func(opts *Options) {
+		opts.WorkerStateInit = init
+		opts.WorkerStateClose = close
+	}
+}
+
+// WithMinIdle makes the purger keep at least n idle workers, even if they have expired.
+func WithMinIdle(n int) Option {
+	return func(opts *Options) {
+		opts.MinIdle = n
+	}
+}
+
+// WithWorkerMaxTasks makes a worker exit after running n tasks, so that a goroutine stack
+// grown by a huge task does not live forever.
+func WithWorkerMaxTasks(n int) Option {
+	return func(opts *Options) {
+		opts.WorkerMaxTasks = n
+	}
+}
+
+// WithWorkerMaxAge makes a worker exit after the first task it finishes once d has passed
+// since it started.
+func WithWorkerMaxAge(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.WorkerMaxAge = d
+	}
+}
+
+// WithAdaptiveConcurrency lets the pool adjust its capacity within [config.Min, config.Max]
+// from the measured task latency and the number of waiting callers, the size passed to the
+// constructor is the initial capacity. It's not supported by PreAlloc or unlimited pools.
+func WithAdaptiveConcurrency(config AdaptiveConfig) Option {
+	return func(opts *Options) {
+		opts.Adaptive = &config
+	}
+}
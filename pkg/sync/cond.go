@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cond works like sync.Cond, except that a waiter can give up waiting once its context is done,
+// and Signal wakes up the waiter with the highest priority rather than the longest waiting one.
+type Cond struct {
+	L sync.Locker
+
+	// Aging raises the priority of a waiter by one for every Aging it has waited,
+	// so that low priority waiters can not starve forever. Zero disables aging.
+	Aging time.Duration
+
+	mu      sync.Mutex
+	waiters list.List //按等待顺序排列，优先级相同时先等待的先被唤醒
+}
+
+type waiter struct {
+	ch    chan struct{}
+	prio  int
+	since time.Time
+}
+
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+func (c *Cond) Wait() {
+	_ = c.WaitContext(context.Background())
+}
+
+// WaitContext atomically unlocks c.L and suspends the caller until it is woken up by
+// Signal/Broadcast or ctx is done, c.L is locked again before returning in both cases.
+func (c *Cond) WaitContext(ctx context.Context) error {
+	return c.WaitPriority(ctx, 0, time.Now())
+}
+
+// WaitPriority works like WaitContext for a waiter of priority prio, since is when the
+// caller started waiting and is used for aging, it may be earlier than now if the caller
+// has to wait again after being woken up.
+func (c *Cond) WaitPriority(ctx context.Context, prio int, since time.Time) error {
+	w := &waiter{ch: make(chan struct{}), prio: prio, since: since}
+	c.mu.Lock()
+	e := c.waiters.PushBack(w)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	var err error
+	select {
+	case <-w.ch:
+	case <-ctx.Done():
+		c.mu.Lock()
+		select {
+		case <-w.ch: //唤醒和取消同时发生时当作被唤醒，避免丢掉这次唤醒
+		default:
+			c.waiters.Remove(e)
+			err = ctx.Err()
+		}
+		c.mu.Unlock()
+	}
+	c.L.Lock()
+	return err
+}
+
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	var (
+		best     *list.Element
+		bestPrio int
+		now      time.Time
+	)
+	if c.Aging > 0 {
+		now = time.Now()
+	}
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		if prio := c.effectivePriority(e.Value.(*waiter), now); best == nil || prio > bestPrio {
+			best, bestPrio = e, prio
+		}
+	}
+	if best != nil {
+		close(c.waiters.Remove(best).(*waiter).ch)
+	}
+	c.mu.Unlock()
+}
+
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(*waiter).ch)
+	}
+	c.waiters.Init()
+	c.mu.Unlock()
+}
+
+func (c *Cond) effectivePriority(w *waiter, now time.Time) int {
+	if c.Aging <= 0 {
+		return w.prio
+	}
+	return w.prio + int(now.Sub(w.since)/c.Aging)
+}
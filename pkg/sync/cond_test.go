@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCondSignal(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	ready := false
+
+	done := make(chan struct{})
+	go func() {
+		mu.Lock()
+		for !ready {
+			c.Wait()
+		}
+		mu.Unlock()
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	ready = true
+	c.Signal()
+	mu.Unlock()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not woken up")
+	}
+}
+
+func TestCondWaitContext(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	mu.Lock()
+	err := c.WaitContext(ctx)
+	mu.Unlock()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.EqualValues(t, 0, c.waiters.Len(), "cancelled waiter should be removed")
+}
+
+func TestCondBroadcast(t *testing.T) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	c := NewCond(&mu)
+	n := 10
+	waiting := 0
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			waiting++
+			c.Wait()
+			mu.Unlock()
+		}()
+	}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return waiting == n
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	c.Broadcast()
+	mu.Unlock()
+	wg.Wait()
+}
+
+func TestCondSignalPriority(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+
+	woken := make(chan int, 3)
+	for _, prio := range []int{0, 5, 1} {
+		go func() {
+			mu.Lock()
+			c.WaitPriority(context.Background(), prio, time.Now())
+			woken <- prio
+			mu.Unlock()
+		}()
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.waiters.Back() != nil && c.waiters.Back().Value.(*waiter).prio == prio
+		}, time.Second, time.Millisecond)
+	}
+
+	for _, want := range []int{5, 1, 0} {
+		c.Signal()
+		require.Equal(t, want, <-woken)
+	}
+}
+
+func TestCondSignalAging(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	c.Aging = time.Millisecond
+
+	woken := make(chan int, 2)
+	start := time.Now()
+	go func() {
+		mu.Lock()
+		c.WaitPriority(context.Background(), 0, start.Add(-time.Second))
+		woken <- 0
+		mu.Unlock()
+	}()
+	go func() {
+		mu.Lock()
+		c.WaitPriority(context.Background(), 10, start)
+		woken <- 10
+		mu.Unlock()
+	}()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.waiters.Len() == 2
+	}, time.Second, time.Millisecond)
+
+	c.Signal()
+	require.Equal(t, 0, <-woken, "the aged waiter should be woken up first")
+	c.Signal()
+	require.Equal(t, 10, <-woken)
+}
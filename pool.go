@@ -1,16 +1,60 @@
 package pppool
 
+import "context"
+
 type Pool struct {
 	*poolCommon
 }
 
 func (p *Pool) Submit(task func()) error {
+	return p.submit(context.Background(), task, nil)
+}
+
+// SubmitContext works like Submit, but gives up with ctx.Err() if ctx is done
+// while the caller is blocked waiting for an available worker.
+func (p *Pool) SubmitContext(ctx context.Context, task func()) error {
+	return p.submit(ctx, task, nil)
+}
+
+// submit hands the task to a worker or the task queue. If the task is dropped by
+// DiscardPolicy or DiscardOldestPolicy, onDiscard (which may be nil) is called with
+// ErrPoolOverload, so that a submitter waiting for the task to finish is not left hanging.
+func (p *Pool) submit(ctx context.Context, task func(), onDiscard func(err error)) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var item any = task
+	if onDiscard != nil && p.tasks != nil {
+		item = discardableTask{run: task, onDiscard: onDiscard}
+	}
+	w, err := p.retrieveWorker(ctx, item, 0)
+	if w != nil {
+		w.inputFunc(task)
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(task, onDiscard)
+	}
+	return err
+}
+
+// SubmitWithPriority works like Submit, but when the pool is full a freed worker goes to the
+// blocked caller with the highest priority first, see WithPriorityAging for starvation.
+// Tasks parked in the task queue are still run in FIFO order.
+func (p *Pool) SubmitWithPriority(prio int, task func()) error {
 	if p.IsClosed() {
 		return ErrorPoolClosed
 	}
-	w, err := p.retrieveWorker()
+	w, err := p.retrieveWorker(context.Background(), task, prio)
 	if w != nil {
 		w.inputFunc(task)
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(task, nil)
 	}
 	return err
 }
@@ -21,10 +65,102 @@ func NewPool(size int, options ...Option) (*Pool, error) {
 		return nil, err
 	}
 	pool := &Pool{poolCommon: pc}
+	pool.scheduler.submit = pool.Submit
 	pool.workerCache.New = func() any { //sync.Pool 复用缓冲没有对象时应该如何做
 		return &goWorker{
 			pool: pool,
-			task: make(chan func(), workerChanCap),
+			task: make(chan func(), pool.workerChanCap()),
+		}
+	}
+	return pool, nil
+}
+
+type PoolWithFunc struct {
+	*poolCommon
+
+	fn func(any)
+}
+
+func (p *PoolWithFunc) Invoke(arg any) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	w, err := p.retrieveWorker(context.Background(), arg, 0)
+	if w != nil {
+		w.inputArg(arg)
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(func() { p.fn(arg) }, nil)
+	}
+	return err
+}
+
+func NewPoolWithFunc(size int, pf func(any), options ...Option) (*PoolWithFunc, error) {
+	if pf == nil {
+		return nil, ErrLackPoolFunc
+	}
+	pc, err := newPool(size, options...)
+	if err != nil {
+		return nil, err
+	}
+	pool := &PoolWithFunc{poolCommon: pc, fn: pf}
+	pool.workerCache.New = func() any {
+		return &goWorkerWithFunc{
+			pool: pool,
+			arg:  make(chan any, pool.workerChanCap()),
+			exit: make(chan struct{}, 1),
+		}
+	}
+	return pool, nil
+}
+
+type PoolWithFuncGeneric[T any] struct {
+	*poolCommon
+
+	fn func(T)
+}
+
+func (p *PoolWithFuncGeneric[T]) Invoke(arg T) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	//只有启用任务队列时才需要把arg装箱成any，T是接口类型时nil不能直接装箱，否则取出时断言会失败
+	var item any
+	if p.tasks != nil {
+		item = genericArg[T]{arg}
+	}
+	w, err := p.retrieveWorker(context.Background(), item, 0)
+	if w != nil {
+		//直接写入chan T，避免装箱成any
+		w.(*goWorkerWithFuncGeneric[T]).arg <- arg
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(func() { p.fn(arg) }, nil)
+	}
+	return err
+}
+
+// genericArg carries an argument of PoolWithFuncGeneric through the task queue.
+type genericArg[T any] struct {
+	v T
+}
+
+func NewPoolWithFuncGeneric[T any](size int, pf func(T), options ...Option) (*PoolWithFuncGeneric[T], error) {
+	if pf == nil {
+		return nil, ErrLackPoolFunc
+	}
+	pc, err := newPool(size, options...)
+	if err != nil {
+		return nil, err
+	}
+	pool := &PoolWithFuncGeneric[T]{poolCommon: pc, fn: pf}
+	pool.workerCache.New = func() any {
+		return &goWorkerWithFuncGeneric[T]{
+			pool: pool,
+			arg:  make(chan T, pool.workerChanCap()),
+			exit: make(chan struct{}, 1),
 		}
 	}
 	return pool, nil
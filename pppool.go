@@ -29,6 +29,12 @@ var (
 	ErrorPoolClosed        = errors.New("the pool has been closed")
 	ErrInvalidPoolExpiry   = errors.New("invalid expiry for pool")
 	ErrInvalidPreAllocSize = errors.New("can not set up a negative capacity under PreAlloc mode")
+	ErrLackPoolFunc        = errors.New("must provide function for pool")
+	ErrTimeout             = errors.New("operation timed out")
+	ErrPoolPaused          = errors.New("the pool has been paused")
+	ErrInvalidPoolSize     = errors.New("invalid size for pool")
+	ErrTuneUnsupported     = errors.New("can not tune the capacity of a PreAlloc or unlimited pool")
+	ErrPoolDraining        = errors.New("the pool is still draining the tasks of its last run")
 
 	workerChanCap = func() int {
 		if runtime.GOMAXPROCS(0) == 1 {
@@ -76,25 +82,52 @@ type poolCommon struct {
 
 	state int32
 
-	cond sync.Cond
+	paused int32 //暂停时拒绝新的任务，已经提交的任务照常执行
 
-	allDone chan struct{}
+	cond *syncx.Cond
+
+	allDone chan struct{} //Release之后最后一个worker退出时关闭，和once一样只在持有lock时读写
 
 	once *sync.Once //并发协程只会执行一次，比如关闭资源、申请资源
 
 	workerCache sync.Pool //对象复用
 
+	tasks chan queuedTask //有界任务队列，没有空闲worker时Submit先把任务放在这里，为nil时表示不启用
+
+	//各优先级上阻塞在retrieveWorker中的调用者数量，持有lock时才能访问
+	waitingByPriority map[int]int
+
 	waiting   int32
 	purgeDone int32
-	purgeCtx  context.Context
-	stopPurge context.CancelFunc
+	stopPurge context.CancelFunc //和stopticktock一样只在持有lock时读写
 
 	ticktockDone int32
-	ticktockCtx  context.Context
 	stopticktock context.CancelFunc
 
+	//统计数据，见Stats
+	submitted atomic.Int64
+	completed atomic.Int64
+	panicked  atomic.Int64
+	rejected  atomic.Int64
+	discarded atomic.Int64
+	abandoned atomic.Int64
+	spawned   atomic.Int64
+	purged    atomic.Int64
+	retired   atomic.Int64
+	waitTime  atomic.Int64
+
+	adjustments atomic.Int64 //自适应控制器调整容量的次数
+
+	waitLatency histogram //从Submit到任务开始执行
+	execLatency histogram //任务执行耗时
+
 	now     atomic.Value
+	name    atomic.Value //注册时的名字，见Register
 	options *Options
+
+	scheduler *scheduler
+
+	adaptive *adaptiveController //为nil时表示不自动调整容量
 }
 
 func newPool(size int, options ...Option) (*poolCommon, error) {
@@ -117,6 +150,10 @@ func newPool(size int, options ...Option) (*poolCommon, error) {
 		opts.Logger = defaultLogger
 	}
 
+	if opts.TaskTimeoutGrace == 0 {
+		opts.TaskTimeoutGrace = DefaultTaskTimeoutGrace
+	}
+
 	p := &poolCommon{
 		capacity: int32(size),
 		allDone:  make(chan struct{}),
@@ -124,23 +161,45 @@ func newPool(size int, options ...Option) (*poolCommon, error) {
 		once:     &sync.Once{},
 		options:  opts,
 	}
+	p.scheduler = newScheduler()
+	p.waitingByPriority = make(map[int]int)
 
-	if p.options.PreAlloc {
-		if size == -1 {
-			return nil, ErrInvalidPreAllocSize
+	if p.options.PreAlloc && size == -1 {
+		return nil, ErrInvalidPreAllocSize
+	}
+	if cfg := opts.Adaptive; cfg != nil {
+		//PreAlloc的loopQueue大小固定，不限容量时Tune也不起作用
+		if opts.PreAlloc || size == -1 {
+			return nil, ErrInvalidAdaptiveConfig
+		}
+		config := *cfg
+		if err := config.validate(size); err != nil {
+			return nil, err
 		}
-		p.workers = newWorkerQueue(queueTypeLoopQueue, size)
-	} else {
-		p.workers = newWorkerQueue(queueTypeStack, 0)
+		opts.Adaptive = &config
+		p.capacity = int32(min(max(size, config.Min), config.Max))
+		p.adaptive = newAdaptiveController(config)
 	}
-	p.cond = *sync.NewCond(p.lock)
+	if opts.TaskQueueSize > 0 {
+		p.tasks = make(chan queuedTask, opts.TaskQueueSize)
+	}
+	p.workers = p.newWorkerQueue()
+	p.cond = syncx.NewCond(p.lock)
+	p.cond.Aging = opts.PriorityAging
 	p.goPurge()    //开启一个协程去refresh过期的worker
 	p.goTicktock() //开启一个协程去更新pool的时间
 
 	return p, nil
 }
 
-func (p *poolCommon) purgeStaleWorkers() {
+func (p *poolCommon) newWorkerQueue() workerQueue {
+	if p.options.PreAlloc {
+		return newWorkerQueue(queueTypeLoopQueue, p.Cap())
+	}
+	return newWorkerQueue(queueTypeStack, 0)
+}
+
+func (p *poolCommon) purgeStaleWorkers(ctx context.Context) {
 	ticker := time.NewTicker(p.options.ExpiryDuration)
 
 	defer func() {
@@ -148,58 +207,146 @@ func (p *poolCommon) purgeStaleWorkers() {
 		atomic.StoreInt32(&p.purgeDone, 1)
 	}()
 
-	purgeCtx := p.purgeCtx
 	for {
 		select {
-		case <-purgeCtx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 		}
 		if p.IsClosed() {
 			break
 		}
+		p.Purge()
+	}
+}
 
-		var isDormant bool
-		p.lock.Lock()
-		staleWorkers := p.workers.refresh(p.options.ExpiryDuration)
-		n := p.Running()
-		isDormant = n == 0 || n == len(staleWorkers)
-		p.lock.Unlock()
+// Purge reaps the workers which have been idle for longer than ExpiryDuration right away.
+func (p *poolCommon) Purge() {
+	if p.IsClosed() {
+		return
+	}
 
-		// clean up the stale workers
-		for i := range staleWorkers {
-			staleWorkers[i].finish()
-			staleWorkers[i] = nil
+	var isDormant bool
+	p.lock.Lock()
+	staleWorkers := p.workers.refresh(p.options.ExpiryDuration)
+	//保留最新的几个过期worker，使空闲worker不少于MinIdle
+	if keep := min(p.options.MinIdle-p.workers.len(), len(staleWorkers)); keep > 0 {
+		now, kept, from := time.Now(), 0, len(staleWorkers)-keep
+		for _, w := range staleWorkers[from:] {
+			w.setLastUsedTime(now)
+			if p.workers.insert(w) != nil {
+				break
+			}
+			kept++
 		}
-		// p.workers.clean()
+		staleWorkers = append(staleWorkers[:from], staleWorkers[from+kept:]...)
+	}
+	n := p.Running()
+	isDormant = n == 0 || n == len(staleWorkers)
+	p.lock.Unlock()
 
-		//There might be a situation where all workers have been cleaned up
-		//while some invokers still are stuck in p.cond.Wait(), then we need to awake those invokers.
-		if isDormant && p.Waiting() > 0 {
-			p.cond.Broadcast()
+	// clean up the stale workers
+	p.purged.Add(int64(len(staleWorkers)))
+	for i := range staleWorkers {
+		staleWorkers[i].finish()
+		staleWorkers[i] = nil
+	}
+	// p.workers.clean()
+
+	//There might be a situation where all workers have been cleaned up
+	//while some invokers still are stuck in p.cond.Wait(), then we need to awake those invokers.
+	if isDormant && p.Waiting() > 0 {
+		p.cond.Broadcast()
+	}
+}
+
+// Warmup spawns up to n idle workers ahead of time without exceeding the capacity,
+// and returns how many were spawned. Combine it with WithMinIdle to keep them around.
+func (p *poolCommon) Warmup(n int) int {
+	if p.IsClosed() {
+		return 0
+	}
+	spawned := 0
+	p.lock.Lock()
+	for ; spawned < n; spawned++ {
+		if capacity := p.Cap(); capacity != -1 && capacity <= p.Running() {
+			break
+		}
+		w := p.workerCache.Get().(worker)
+		w.setLastUsedTime(time.Now())
+		w.run()
+		p.spawned.Add(1)
+		if p.pollTask(w) {
+			continue
+		}
+		if err := p.workers.insert(w); err != nil {
+			w.finish()
+			break
 		}
+		p.cond.Signal()
 	}
+	p.lock.Unlock()
+	return spawned
 }
 
 const nowTimeUpdateInterval = 500 * time.Millisecond
 
-func (p *poolCommon) ticktock() {
-	ticker := time.NewTicker(nowTimeUpdateInterval)
+// ticktock updates the clock of the pool and drives the timing wheel of the scheduler,
+// it switches to the finer wheel tick only while there are pending timers.
+func (p *poolCommon) ticktock(ctx context.Context) {
+	var (
+		ticker      = time.NewTicker(nowTimeUpdateInterval)
+		wheelTicker *time.Ticker
+		wheelC      <-chan time.Time
+		dispatching bool
+		adaptC      <-chan time.Time
+	)
+	if p.adaptive != nil {
+		adaptTicker := time.NewTicker(p.adaptive.config.Interval)
+		defer adaptTicker.Stop()
+		adaptC = adaptTicker.C
+	}
 	defer func() {
 		ticker.Stop()
+		if wheelTicker != nil {
+			wheelTicker.Stop()
+		}
 		atomic.StoreInt32(&p.ticktockDone, 1)
 	}()
-	ticktockCtx := p.ticktockCtx
+	wheel := p.scheduler.wheel
 	for {
 		select {
-		case <-ticktockCtx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
+		case <-wheelC:
+		case <-wheel.wakeup:
+		case <-adaptC:
+			if !p.IsClosed() {
+				p.adaptive.adjust(p)
+			}
+			continue
 		}
 		if p.IsClosed() {
 			break
 		}
-		p.now.Store(time.Now())
+		now := time.Now()
+		p.now.Store(now)
+
+		if timers := wheel.advance(now); len(timers) > 0 {
+			if !dispatching {
+				dispatching = true
+				go p.dispatchScheduled(ctx)
+			}
+			p.scheduler.push(timers)
+		}
+		if pending := wheel.len() > 0; pending && wheelTicker == nil {
+			wheelTicker = time.NewTicker(wheel.tick)
+			wheelC = wheelTicker.C
+		} else if !pending && wheelTicker != nil {
+			wheelTicker.Stop()
+			wheelTicker, wheelC = nil, nil
+		}
 	}
 }
 
@@ -208,20 +355,38 @@ func (p *poolCommon) goPurge() {
 		return
 	}
 
-	p.purgeCtx, p.stopPurge = context.WithCancel(context.Background())
-	go p.purgeStaleWorkers()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopPurge = cancel
+	go p.purgeStaleWorkers(ctx)
 }
 
 func (p *poolCommon) goTicktock() {
 	p.now.Store(time.Now())
-	p.ticktockCtx, p.stopticktock = context.WithCancel(context.Background())
-	go p.ticktock()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopticktock = cancel
+	go p.ticktock(ctx)
 }
 
 func (p *poolCommon) Waiting() int {
 	return int(atomic.LoadInt32(&p.waiting))
 }
 
+// Queued returns the number of tasks parked in the task queue.
+func (p *poolCommon) Queued() int {
+	return len(p.tasks)
+}
+
+// WaitingByPriority returns the number of callers blocked waiting for a worker per priority.
+func (p *poolCommon) WaitingByPriority() map[int]int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	m := make(map[int]int, len(p.waitingByPriority))
+	for prio, n := range p.waitingByPriority {
+		m[prio] = n
+	}
+	return m
+}
+
 func (p *poolCommon) Running() int {
 	return int(atomic.LoadInt32(&p.running))
 }
@@ -238,10 +403,32 @@ func (p *poolCommon) IsClosed() bool {
 	return atomic.LoadInt32(&p.state) == CLOSED
 }
 
+// Pause makes new submissions fail with ErrPoolPaused until Resume is called,
+// tasks which have already been submitted keep running.
+func (p *poolCommon) Pause() {
+	atomic.StoreInt32(&p.paused, 1)
+}
+
+func (p *poolCommon) Resume() {
+	atomic.StoreInt32(&p.paused, 0)
+}
+
+func (p *poolCommon) IsPaused() bool {
+	return atomic.LoadInt32(&p.paused) == 1
+}
+
+// Options returns a copy of the options of the pool.
+func (p *poolCommon) Options() Options {
+	return *p.options
+}
+
 func (p *poolCommon) Release() {
 	if !atomic.CompareAndSwapInt32(&p.state, OPEND, CLOSED) {
 		return
 	}
+	p.scheduler.reset()
+	p.unregister()
+	p.lock.Lock()
 	if p.stopPurge != nil {
 		p.stopPurge()
 		p.stopPurge = nil
@@ -250,25 +437,153 @@ func (p *poolCommon) Release() {
 		p.stopticktock()
 		p.stopticktock = nil
 	}
-	p.lock.Lock()
 	p.workers.reset()
 	p.lock.Unlock()
 	p.cond.Broadcast()
 }
 
+// Reboot reopens a released pool, the worker queue (including the preallocated ring)
+// is rebuilt since Release has reset it. It fails with ErrPoolDraining until the tasks
+// and the background goroutines of the last run have all exited, see ReleaseTimeout.
+func (p *poolCommon) Reboot() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.IsClosed() {
+		return nil
+	}
+	//旧的purge和ticktock协程退出前不能重置done标志，否则它们的defer会覆盖新的值
+	if p.Running() > 0 || atomic.LoadInt32(&p.ticktockDone) == 0 ||
+		(!p.options.DisablePurge && atomic.LoadInt32(&p.purgeDone) == 0) {
+		return ErrPoolDraining
+	}
+	p.workers = p.newWorkerQueue()
+	p.allDone = make(chan struct{})
+	p.once = &sync.Once{}
+
+	atomic.StoreInt32(&p.purgeDone, 0)
+	p.goPurge()
+	atomic.StoreInt32(&p.ticktockDone, 0)
+	p.goTicktock()
+	atomic.StoreInt32(&p.state, OPEND)
+	return nil
+}
+
+func (p *poolCommon) ReleaseTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.ReleaseContext(ctx)
+}
+
+const releaseCheckInterval = 10 * time.Millisecond
+
+// ReleaseContext closes the pool and waits until every running task has finished
+// and the purge and ticktock goroutines have exited. It waits as well if the pool
+// has already been closed by Release, e.g. from the admin handler.
+func (p *poolCommon) ReleaseContext(ctx context.Context) error {
+	p.Release()
+
+	p.lock.Lock()
+	//没有正在运行的worker时，不会有worker去关闭allDone
+	if p.Running() == 0 {
+		p.once.Do(func() {
+			close(p.allDone)
+		})
+	}
+	allDone := p.allDone
+	p.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return releaseErr(ctx)
+	case <-allDone:
+	}
+
+	ticker := time.NewTicker(releaseCheckInterval)
+	defer ticker.Stop()
+	for {
+		if (p.options.DisablePurge || atomic.LoadInt32(&p.purgeDone) == 1) && atomic.LoadInt32(&p.ticktockDone) == 1 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return releaseErr(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+func releaseErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
+
 func (p *poolCommon) Cap() int {
 	return int(atomic.LoadInt32(&p.capacity))
 }
 
+// Tune changes the capacity of the pool. It fails with ErrTuneUnsupported for pools with
+// unlimited capacity and for PreAlloc pools, whose worker ring is allocated once with the
+// initial size. Growing wakes up the callers blocked in retrieveWorker, shrinking lets the
+// surplus workers retire the next time they come back through revertWorker. With a task
+// queue the surplus workers keep draining the queue first, so a shrink only takes effect
+// once the queue is empty.
+func (p *poolCommon) Tune(size int) error {
+	if size <= 0 {
+		return ErrInvalidPoolSize
+	}
+	capacity := p.Cap()
+	if capacity == -1 || p.options.PreAlloc {
+		return ErrTuneUnsupported
+	}
+	if size == capacity {
+		return nil
+	}
+	atomic.StoreInt32(&p.capacity, int32(size))
+	if size > capacity {
+		//持有锁再唤醒，避免retrieveWorker检查完capacity但还没Wait时丢失唤醒
+		p.lock.Lock()
+		if size-capacity == 1 {
+			p.cond.Signal()
+		} else {
+			p.cond.Broadcast()
+		}
+		p.lock.Unlock()
+	}
+	return nil
+}
+
 func (p *poolCommon) addRunning(delta int) int {
 	return int(atomic.AddInt32(&p.running, int32(delta)))
 }
 
+// workerDone gives back the capacity held by a worker, and closes allDone once the last
+// worker of a released pool is gone. It holds p.lock so that Reboot can not replace allDone
+// and once in between.
+func (p *poolCommon) workerDone() {
+	p.lock.Lock()
+	if p.addRunning(-1) == 0 && p.IsClosed() {
+		p.once.Do(func() {
+			close(p.allDone)
+		})
+	}
+	p.lock.Unlock()
+}
+
 func (p *poolCommon) addWaiting(delta int) {
 	atomic.AddInt32(&p.waiting, int32(delta))
 }
 
-func (p *poolCommon) retrieveWorker() (w worker, err error) {
+// retrieveWorker returns an available worker to run the task. If the task queue is enabled and
+// all workers are busy, item is parked in the task queue instead and both return values are nil.
+// Callers blocked waiting for a worker are woken up in order of prio.
+func (p *poolCommon) retrieveWorker(ctx context.Context, item any, prio int) (w worker, err error) {
+	if p.IsPaused() {
+		return nil, ErrPoolPaused
+	}
+	var since time.Time
+	submitted := time.Now()
 	p.lock.Lock()
 
 retry:
@@ -276,6 +591,8 @@ retry:
 	//直接中workers中取一个worker
 	if w = p.workers.detach(); w != nil {
 		p.lock.Unlock()
+		w.setSubmitTime(submitted)
+		p.submitted.Add(1)
 		return
 	}
 	//if worker queue is empry, and we don't run out of the pool capacity
@@ -283,18 +600,58 @@ retry:
 	if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
 		p.lock.Unlock()
 		w = p.workerCache.Get().(worker)
+		w.setSubmitTime(submitted)
 		w.run()
+		p.spawned.Add(1)
+		p.submitted.Add(1)
 		return
 	}
 
+	//all workers are busy, park the task in the task queue if there is still room
+	if p.tasks != nil {
+		select {
+		case p.tasks <- queuedTask{arg: item, submitted: submitted}:
+			p.lock.Unlock()
+			p.submitted.Add(1)
+			return nil, nil
+		default:
+		}
+	}
+
 	//Bail out early if it's in nonblocking mode or the number of pending callers reaches the maximum limit value
 	if p.options.Nonblocking || (p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks) {
+		p.rejected.Add(1)
+		if p.tasks != nil && p.options.RejectionPolicy == DiscardOldestPolicy {
+			//只有持有锁时才会修改任务队列，走到这里时队列一定是满的
+			oldest := <-p.tasks
+			p.tasks <- queuedTask{arg: item, submitted: submitted}
+			p.discarded.Add(1)
+			p.lock.Unlock()
+			p.submitted.Add(1)
+			if t, ok := oldest.arg.(discardableTask); ok {
+				t.onDiscard(ErrPoolOverload)
+			}
+			return nil, nil
+		}
 		p.lock.Unlock()
 		return nil, ErrPoolOverload
 	}
+	if since.IsZero() {
+		since = time.Now()
+	}
 	p.addWaiting(1)
-	p.cond.Wait()
+	p.waitingByPriority[prio]++
+	waitStart := time.Now()
+	err = p.cond.WaitPriority(ctx, prio, since)
+	p.waitTime.Add(int64(time.Since(waitStart)))
+	if p.waitingByPriority[prio]--; p.waitingByPriority[prio] == 0 {
+		delete(p.waitingByPriority, prio)
+	}
 	p.addWaiting(-1)
+	if err != nil {
+		p.lock.Unlock()
+		return nil, err
+	}
 	if p.IsClosed() {
 		p.lock.Unlock()
 		return nil, ErrorPoolClosed
@@ -303,8 +660,21 @@ retry:
 	goto retry
 }
 
+// revertWorker puts the worker back into the worker queue, or hands it the oldest task
+// in the task queue instead. It returns false if the worker should exit.
 func (p *poolCommon) revertWorker(worker worker) bool {
 	if capacity := p.Cap(); (capacity > 0 && p.Running() > capacity) || p.IsClosed() {
+		//queued tasks have been accepted by Submit, keep draining them even if the pool is closed or
+		//shrunk by Tune: surplus workers may all see the same Running() and exit together, which
+		//would leave the queue without any worker to poll it
+		if p.tasks != nil {
+			p.lock.Lock()
+			polled := p.pollTask(worker)
+			p.lock.Unlock()
+			if polled {
+				return true
+			}
+		}
 		p.cond.Broadcast()
 		return false
 	}
@@ -312,11 +682,17 @@ func (p *poolCommon) revertWorker(worker worker) bool {
 
 	p.lock.Lock()
 
+	//检查任务队列和放回worker必须在同一次加锁里完成，否则可能有任务在这之间入队，而worker已经空闲了
+	if p.pollTask(worker) {
+		p.lock.Unlock()
+		return true
+	}
 	if p.IsClosed() {
-		p.lock.Lock()
+		p.lock.Unlock()
 		return false
 	}
 	if err := p.workers.insert(worker); err != nil {
+		p.lock.Unlock()
 		return false
 	}
 	// notfiy the invoker stuct in "retrieveWorker()" of there is an avalible worker in the worker queue
@@ -324,3 +700,41 @@ func (p *poolCommon) revertWorker(worker worker) bool {
 	p.lock.Unlock()
 	return true
 }
+
+type queuedTask struct {
+	arg       any
+	submitted time.Time
+}
+
+// pollTask hands the oldest queued task to the worker, p.lock must be held.
+// The worker is the caller itself and its channel is buffered when the task
+// queue is enabled, so the handoff never blocks. If the handoff panics, p.lock
+// is released before the panic goes on.
+func (p *poolCommon) pollTask(worker worker) bool {
+	if p.tasks == nil {
+		return false
+	}
+	select {
+	case t := <-p.tasks:
+		worker.setSubmitTime(t.submitted)
+		defer func() {
+			if r := recover(); r != nil {
+				p.lock.Unlock()
+				panic(r)
+			}
+		}()
+		worker.inputArg(t.arg)
+		// a slot in the task queue is free now
+		p.cond.Signal()
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *poolCommon) workerChanCap() int {
+	if p.tasks != nil {
+		return 1
+	}
+	return workerChanCap
+}
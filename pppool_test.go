@@ -1 +1,757 @@
 package pppool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolWithFuncInvoke(t *testing.T) {
+	var (
+		sum int32
+		wg  sync.WaitGroup
+	)
+	p, err := NewPoolWithFunc(10, func(arg any) {
+		atomic.AddInt32(&sum, arg.(int32))
+		wg.Done()
+	})
+	require.NoError(t, err)
+	defer p.Release()
+
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		require.NoError(t, p.Invoke(int32(1)))
+	}
+	wg.Wait()
+	require.EqualValues(t, 1000, atomic.LoadInt32(&sum))
+	require.LessOrEqual(t, p.Running(), 10)
+}
+
+func TestPoolWithFuncNilArg(t *testing.T) {
+	done := make(chan any, 1)
+	p, err := NewPoolWithFunc(1, func(arg any) {
+		done <- arg
+	})
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Invoke(nil))
+	require.Nil(t, <-done)
+	require.NoError(t, p.Invoke(1))
+	require.EqualValues(t, 1, <-done)
+}
+
+func TestPoolWithFuncLackFunc(t *testing.T) {
+	_, err := NewPoolWithFunc(10, nil)
+	require.ErrorIs(t, err, ErrLackPoolFunc)
+}
+
+func TestPoolWithFuncNonblocking(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPoolWithFunc(1, func(any) {
+		<-block
+	}, WithNonblocking(true))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Invoke(nil))
+	require.ErrorIs(t, p.Invoke(nil), ErrPoolOverload)
+	close(block)
+}
+
+func TestPoolWithFuncGenericInvoke(t *testing.T) {
+	var (
+		sum int64
+		wg  sync.WaitGroup
+	)
+	p, err := NewPoolWithFuncGeneric(10, func(i int64) {
+		atomic.AddInt64(&sum, i)
+		wg.Done()
+	})
+	require.NoError(t, err)
+	defer p.Release()
+
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		require.NoError(t, p.Invoke(int64(i)))
+	}
+	wg.Wait()
+	require.EqualValues(t, 999*1000/2, atomic.LoadInt64(&sum))
+	require.EqualValues(t, 10, p.Cap())
+	require.EqualValues(t, p.Cap()-p.Running(), p.Free())
+	require.EqualValues(t, 0, p.Waiting())
+
+	p.Release()
+	require.ErrorIs(t, p.Invoke(1), ErrorPoolClosed)
+}
+
+func TestPoolWithFuncGenericZeroValue(t *testing.T) {
+	done := make(chan string, 1)
+	p, err := NewPoolWithFuncGeneric(1, func(s string) {
+		done <- s
+	})
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Invoke(""))
+	require.Equal(t, "", <-done)
+	require.NoError(t, p.Invoke("pppool"))
+	require.Equal(t, "pppool", <-done)
+}
+
+func TestPoolTune(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Submit(func() { <-block }))
+
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- p.Submit(func() { <-block })
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, p.Tune(2))
+	require.EqualValues(t, 2, p.Cap())
+	require.NoError(t, <-submitted)
+	require.EqualValues(t, 2, p.Running())
+
+	require.NoError(t, p.Tune(1))
+	require.EqualValues(t, 1, p.Cap())
+	close(block)
+	require.Eventually(t, func() bool { return p.Running() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestPoolTuneUnsupported(t *testing.T) {
+	p, err := NewPool(10, WithPreAlloc(true))
+	require.NoError(t, err)
+	defer p.Release()
+	require.ErrorIs(t, p.Tune(20), ErrTuneUnsupported)
+	require.EqualValues(t, 10, p.Cap())
+
+	p2, err := NewPool(-1)
+	require.NoError(t, err)
+	defer p2.Release()
+	require.ErrorIs(t, p2.Tune(20), ErrTuneUnsupported)
+	require.EqualValues(t, -1, p2.Cap())
+
+	require.ErrorIs(t, p2.Tune(0), ErrInvalidPoolSize)
+	require.EqualValues(t, -1, p2.Cap())
+}
+
+func TestPoolReleaseTimeout(t *testing.T) {
+	var done int32
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(func() {
+			time.Sleep(100 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+		}))
+	}
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	require.EqualValues(t, 10, atomic.LoadInt32(&done))
+	require.EqualValues(t, 0, p.Running())
+	require.EqualValues(t, 1, atomic.LoadInt32(&p.purgeDone))
+	require.EqualValues(t, 1, atomic.LoadInt32(&p.ticktockDone))
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+}
+
+func TestPoolReleaseTimeoutAfterRelease(t *testing.T) {
+	var done int32
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() {
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+		}))
+	}
+	p.Release()
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	require.EqualValues(t, 5, atomic.LoadInt32(&done))
+	require.EqualValues(t, 0, p.Running())
+}
+
+func TestPoolReleaseTimeoutExpired(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	p, err := NewPoolWithFunc(1, func(any) { <-block })
+	require.NoError(t, err)
+	require.NoError(t, p.Invoke(nil))
+	require.ErrorIs(t, p.ReleaseTimeout(50*time.Millisecond), ErrTimeout)
+}
+
+func TestPoolReleaseContextIdle(t *testing.T) {
+	p, err := NewPool(10, WithDisablePurge(true))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	require.NoError(t, p.ReleaseContext(ctx))
+}
+
+func TestPoolReboot(t *testing.T) {
+	for _, preAlloc := range []bool{false, true} {
+		var done int32
+		p, err := NewPool(10, WithPreAlloc(preAlloc))
+		require.NoError(t, err)
+		require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+		require.NoError(t, p.ReleaseTimeout(3*time.Second))
+		require.ErrorIs(t, p.Submit(func() {}), ErrorPoolClosed)
+
+		require.NoError(t, p.Reboot())
+		require.False(t, p.IsClosed())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+		}
+		require.NoError(t, p.ReleaseTimeout(3*time.Second))
+		require.EqualValues(t, 101, atomic.LoadInt32(&done))
+	}
+}
+
+func TestPoolRebootDraining(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(2)
+	require.NoError(t, err)
+	require.NoError(t, p.Submit(func() { <-block }))
+	p.Release()
+	require.ErrorIs(t, p.Reboot(), ErrPoolDraining)
+	require.True(t, p.IsClosed())
+
+	close(block)
+	require.Eventually(t, func() bool { return p.Reboot() == nil }, time.Second, time.Millisecond)
+	require.False(t, p.IsClosed())
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+}
+
+func TestPoolRebootStress(t *testing.T) {
+	p, err := NewPool(4, WithExpiryDuration(time.Millisecond), WithTaskQueue(4))
+	require.NoError(t, err)
+
+	var running atomic.Int32
+	task := func() {
+		running.Add(1)
+		time.Sleep(100 * time.Microsecond)
+		running.Add(-1)
+	}
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 8; j++ {
+			go func() { _ = p.Submit(task) }()
+		}
+		p.Release()
+		require.Eventually(t, func() bool { return p.Reboot() == nil }, time.Second, 100*time.Microsecond)
+	}
+	for j := 0; j < 8; j++ {
+		require.NoError(t, p.Submit(task))
+	}
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	require.EqualValues(t, 0, running.Load())
+	require.Equal(t, 0, p.Running())
+}
+
+func TestPoolSubmitContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.SubmitContext(context.Background(), func() { <-block }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = p.SubmitContext(ctx, func() {})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.EqualValues(t, 0, p.Waiting())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, p.SubmitContext(ctx, func() {}), context.Canceled)
+	require.EqualValues(t, 0, p.Waiting())
+}
+
+func TestPoolRejectionPolicy(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	p, err := NewPool(1, WithNonblocking(true), WithRejectionPolicy(CallerRunsPolicy))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Submit(func() { <-block }))
+	ran := false
+	require.NoError(t, p.Submit(func() { ran = true }))
+	require.True(t, ran, "task should run on the caller goroutine")
+
+	p2, err := NewPool(1, WithNonblocking(true), WithRejectionPolicy(DiscardPolicy))
+	require.NoError(t, err)
+	defer p2.Release()
+	require.NoError(t, p2.Submit(func() { <-block }))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p2.Submit(func() {}))
+	}
+	require.EqualValues(t, 5, p2.Discarded())
+
+	var rejected []func()
+	p3, err := NewPoolWithFunc(1, func(any) { <-block }, WithNonblocking(true),
+		WithRejectionHandler(func(task func()) { rejected = append(rejected, task) }))
+	require.NoError(t, err)
+	defer p3.Release()
+	require.NoError(t, p3.Invoke(nil))
+	require.NoError(t, p3.Invoke(nil))
+	require.Len(t, rejected, 1)
+
+	p4, err := NewPool(1, WithNonblocking(true))
+	require.NoError(t, err)
+	defer p4.Release()
+	require.NoError(t, p4.Submit(func() { <-block }))
+	require.ErrorIs(t, p4.Submit(func() {}), ErrPoolOverload)
+}
+
+func TestPoolTaskQueue(t *testing.T) {
+	block := make(chan struct{})
+	var done int32
+	p, err := NewPool(2, WithTaskQueue(10), WithNonblocking(true))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.Submit(func() {
+			<-block
+			atomic.AddInt32(&done, 1)
+		}))
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+	}
+	require.EqualValues(t, 10, p.Queued())
+	require.EqualValues(t, 2, p.Running())
+	require.ErrorIs(t, p.Submit(func() {}), ErrPoolOverload)
+
+	close(block)
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	require.EqualValues(t, 12, atomic.LoadInt32(&done))
+	require.EqualValues(t, 0, p.Queued())
+}
+
+func TestPoolTaskQueuePanic(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1, WithTaskQueue(4), WithPanicHandler(func(any) {}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Submit(func() {
+		<-block
+		panic("boom")
+	}))
+	var done atomic.Bool
+	require.NoError(t, p.Submit(func() { done.Store(true) }))
+	require.EqualValues(t, 1, p.Queued())
+
+	//唯一的worker panic退出后，队列里的任务由新的worker接手
+	close(block)
+	require.Eventually(t, done.Load, time.Second, 5*time.Millisecond)
+	require.EqualValues(t, 0, p.Queued())
+}
+
+func TestPoolTaskQueueDiscardOldest(t *testing.T) {
+	block := make(chan struct{})
+	var got []int
+	p, err := NewPoolWithFuncGeneric(1, func(i int) {
+		<-block
+		got = append(got, i)
+	}, WithTaskQueue(2), WithNonblocking(true), WithRejectionPolicy(DiscardOldestPolicy))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Invoke(i))
+	}
+	require.EqualValues(t, 2, p.Queued())
+	require.EqualValues(t, 2, p.Discarded())
+
+	close(block)
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	require.Equal(t, []int{0, 3, 4}, got)
+}
+
+func TestPoolTaskQueueBlocking(t *testing.T) {
+	var done int32
+	p, err := NewPoolWithFunc(2, func(any) {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&done, 1)
+	}, WithTaskQueue(4))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Invoke(nil))
+	}
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	require.EqualValues(t, 100, atomic.LoadInt32(&done))
+}
+
+func TestPoolSubmitWithPriority(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(1)
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Submit(func() { <-block }))
+
+	order := make(chan int, 3)
+	for i, prio := range []int{0, 10, 5} {
+		go func() {
+			_ = p.SubmitWithPriority(prio, func() { order <- prio })
+		}()
+		require.Eventually(t, func() bool { return p.Waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+	require.Equal(t, map[int]int{0: 1, 5: 1, 10: 1}, p.WaitingByPriority())
+
+	close(block)
+	for _, want := range []int{10, 5, 0} {
+		require.Equal(t, want, <-order)
+	}
+	require.Empty(t, p.WaitingByPriority())
+}
+
+func TestPoolSubmitAfter(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	done := make(chan time.Time, 2)
+	start := time.Now()
+	require.NoError(t, p.SubmitAfter(100*time.Millisecond, func() { done <- time.Now() }))
+	require.NoError(t, p.SubmitAt(start.Add(50*time.Millisecond), func() { done <- time.Now() }))
+
+	first, second := <-done, <-done
+	require.GreaterOrEqual(t, first.Sub(start), 50*time.Millisecond)
+	require.GreaterOrEqual(t, second.Sub(start), 100*time.Millisecond)
+	require.True(t, second.After(first))
+}
+
+func TestPoolSchedule(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+
+	var count int32
+	cancel := p.Schedule(20*time.Millisecond, func() { atomic.AddInt32(&count, 1) })
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 3 }, 3*time.Second, time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	n := atomic.LoadInt32(&count)
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, n, atomic.LoadInt32(&count))
+
+	p.Schedule(20*time.Millisecond, func() { atomic.AddInt32(&count, 1) })
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+	n = atomic.LoadInt32(&count)
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, n, atomic.LoadInt32(&count), "scheduled task should stop on Release")
+	require.EqualValues(t, 0, p.scheduler.wheel.len())
+	require.ErrorIs(t, p.SubmitAfter(time.Millisecond, func() {}), ErrorPoolClosed)
+}
+
+func TestPoolSubmitWithTimeout(t *testing.T) {
+	var timedOut int32
+	p, err := NewPool(1,
+		WithTaskTimeoutGrace(20*time.Millisecond),
+		WithOnTaskTimeout(func(time.Duration) { atomic.AddInt32(&timedOut, 1) }))
+	require.NoError(t, err)
+	defer p.Release()
+
+	done := make(chan error, 1)
+	require.NoError(t, p.SubmitWithTimeout(20*time.Millisecond, func(ctx context.Context) {
+		<-ctx.Done()
+		done <- ctx.Err()
+	}))
+	require.ErrorIs(t, <-done, context.DeadlineExceeded)
+	require.EqualValues(t, 0, p.Abandoned())
+
+	hung := make(chan struct{})
+	require.NoError(t, p.SubmitWithTimeout(20*time.Millisecond, func(context.Context) { <-hung }))
+	require.Eventually(t, func() bool { return p.Abandoned() == 1 }, time.Second, time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt32(&timedOut))
+	require.EqualValues(t, 0, p.Running())
+
+	//the hung task does not hold the only slot of the pool anymore
+	ran := make(chan struct{})
+	require.NoError(t, p.Submit(func() { close(ran) }))
+	<-ran
+
+	close(hung)
+	require.Eventually(t, func() bool { return p.Running() == 1 }, time.Second, time.Millisecond)
+	require.LessOrEqual(t, p.Running(), p.Cap())
+}
+
+func TestPoolSubmitWithTimeoutTaskQueue(t *testing.T) {
+	p, err := NewPool(1, WithTaskQueue(4),
+		WithTaskTimeoutGrace(10*time.Millisecond), WithOnTaskTimeout(func(time.Duration) {}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	hung := make(chan struct{})
+	defer close(hung)
+	require.NoError(t, p.SubmitWithTimeout(10*time.Millisecond, func(context.Context) { <-hung }))
+	ran := make(chan struct{})
+	require.NoError(t, p.Submit(func() { close(ran) }))
+	require.EqualValues(t, 1, p.Queued())
+
+	//the queued task gets the slot given back by the abandoned task
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("queued task did not run after the hung task was abandoned")
+	}
+	require.EqualValues(t, 1, p.Abandoned())
+}
+
+func TestPoolSubmitWithTimeoutCallerRuns(t *testing.T) {
+	p, err := NewPool(1, WithNonblocking(true), WithRejectionPolicy(CallerRunsPolicy),
+		WithTaskTimeoutGrace(10*time.Millisecond), WithOnTaskTimeout(func(time.Duration) {}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, p.Submit(func() { <-block }))
+
+	//the pool is full, so the hung task runs on the submitting goroutine
+	hung := make(chan struct{})
+	go func() { _ = p.SubmitWithTimeout(10*time.Millisecond, func(context.Context) { <-hung }) }()
+	require.Eventually(t, func() bool { return p.Abandoned() == 1 }, time.Second, time.Millisecond)
+	require.EqualValues(t, 1, p.Running(), "the worker is still busy")
+
+	ran := make(chan struct{})
+	require.NoError(t, p.Submit(func() { close(ran) }))
+	<-ran
+	require.EqualValues(t, 1, p.Running())
+	require.EqualValues(t, 1, p.Stats().Spawned)
+
+	close(hung)
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 1, p.Running())
+}
+
+func TestPoolStats(t *testing.T) {
+	block := make(chan struct{})
+	p, err := NewPool(2, WithNonblocking(true), WithPanicHandler(func(any) {}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Submit(func() { panic("boom") }))
+	require.Eventually(t, func() bool { return p.Stats().Panicked == 1 }, time.Second, time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.Submit(func() { <-block }))
+	}
+	require.ErrorIs(t, p.Submit(func() {}), ErrPoolOverload)
+
+	s := p.Stats()
+	require.EqualValues(t, 2, s.Capacity)
+	require.EqualValues(t, 2, s.Running)
+	require.EqualValues(t, 0, s.Idle)
+	require.EqualValues(t, 3, s.Submitted)
+	require.EqualValues(t, 1, s.Rejected)
+	require.EqualValues(t, 3, s.Spawned)
+
+	close(block)
+	require.Eventually(t, func() bool {
+		s := p.Stats()
+		return s.Completed == 2 && s.Idle == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestPoolStatsPurgedAndWaitTime(t *testing.T) {
+	p, err := NewPool(1, WithExpiryDuration(50*time.Millisecond))
+	require.NoError(t, err)
+	defer p.Release()
+
+	block := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-block }))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(block)
+	}()
+	require.NoError(t, p.Submit(func() {}))
+	require.Greater(t, p.Stats().WaitTime, time.Duration(0))
+
+	require.Eventually(t, func() bool { return p.Stats().Purged == 1 }, 3*time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 0, p.Stats().Idle)
+}
+
+func TestPoolLatencyHistograms(t *testing.T) {
+	p, err := NewPool(1, WithTaskQueue(10))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() { time.Sleep(10 * time.Millisecond) }))
+	}
+	require.NoError(t, p.ReleaseTimeout(3*time.Second))
+
+	s := p.Stats()
+	require.EqualValues(t, 5, s.ExecLatency.Count)
+	require.EqualValues(t, 5, s.WaitLatency.Count)
+	require.GreaterOrEqual(t, s.ExecLatency.Sum, 50*time.Millisecond)
+	require.Greater(t, s.ExecLatency.P50, 8*time.Millisecond)
+	//queued tasks wait for the ones before them
+	require.Greater(t, s.WaitLatency.P99, 20*time.Millisecond)
+}
+
+func TestPoolPause(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	p.Pause()
+	require.True(t, p.IsPaused())
+	require.ErrorIs(t, p.Submit(func() {}), ErrPoolPaused)
+	p.Resume()
+	require.NoError(t, p.Submit(func() {}))
+}
+
+func TestWorkerHooks(t *testing.T) {
+	var started, exited, before, after, panicked atomic.Int32
+	p, err := NewPool(2,
+		WithPanicHandler(func(any) {}),
+		WithOnWorkerStart(func() { started.Add(1) }),
+		WithOnWorkerExit(func() { exited.Add(1) }),
+		WithBeforeTask(func() { before.Add(1) }),
+		WithAfterTask(func(d time.Duration, p bool) {
+			after.Add(1)
+			if p {
+				panicked.Add(1)
+			}
+		}),
+	)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		require.NoError(t, p.Submit(func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+		}))
+	}
+	wg.Wait()
+	require.NoError(t, p.Submit(func() { panic("boom") }))
+	require.Eventually(t, func() bool { return after.Load() == 11 }, time.Second, time.Millisecond)
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+
+	require.EqualValues(t, 11, before.Load())
+	require.EqualValues(t, 1, panicked.Load())
+	require.GreaterOrEqual(t, started.Load(), int32(2))
+	require.Equal(t, started.Load(), exited.Load())
+}
+
+func TestWorkerHookPanic(t *testing.T) {
+	var hookPanics, afterPanicked atomic.Int32
+	var once atomic.Bool
+	p, err := NewPool(1,
+		WithPanicHandler(func(any) { hookPanics.Add(1) }),
+		WithOnWorkerStart(func() {
+			if once.CompareAndSwap(false, true) {
+				panic("hook")
+			}
+		}),
+		WithAfterTask(func(d time.Duration, panicked bool) {
+			if panicked {
+				afterPanicked.Add(1)
+			}
+		}),
+	)
+	require.NoError(t, err)
+	defer p.Release()
+
+	//the panicking hook does not take down the worker with the task it's about to run
+	ran := make(chan struct{})
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.Submit(func() { close(ran) }) }()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("task was lost after OnWorkerStart panicked")
+	}
+	require.NoError(t, <-submitted)
+	require.EqualValues(t, 1, hookPanics.Load())
+	require.EqualValues(t, 0, afterPanicked.Load())
+	require.EqualValues(t, 0, p.Stats().Panicked)
+}
+
+func TestPoolMinIdleWarmup(t *testing.T) {
+	p, err := NewPool(4, WithExpiryDuration(20*time.Millisecond), WithMinIdle(2))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.Equal(t, 4, p.Warmup(10))
+	require.Equal(t, 4, p.Running())
+	require.Equal(t, 4, p.Stats().Idle)
+	require.Equal(t, 0, p.Warmup(1))
+
+	require.Eventually(t, func() bool { return p.Running() == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 2, p.Running())
+	require.Equal(t, 2, p.Stats().Idle)
+
+	require.NoError(t, p.Submit(func() {}))
+	require.EqualValues(t, 4, p.Stats().Spawned)
+}
+
+func TestPoolWorkerRecycling(t *testing.T) {
+	p, err := NewPool(2, WithTaskQueue(32), WithWorkerMaxTasks(3))
+	require.NoError(t, err)
+	defer p.Release()
+
+	var wg sync.WaitGroup
+	var n atomic.Int32
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		require.NoError(t, p.Submit(func() {
+			defer wg.Done()
+			n.Add(1)
+			time.Sleep(time.Millisecond)
+		}))
+	}
+	wg.Wait()
+	require.EqualValues(t, 30, n.Load())
+	require.EqualValues(t, 10, p.Stats().Retired)
+	require.LessOrEqual(t, p.Running(), 2)
+
+	pa, err := NewPoolWithFunc(1, func(any) { time.Sleep(10 * time.Millisecond) }, WithWorkerMaxAge(15*time.Millisecond))
+	require.NoError(t, err)
+	defer pa.Release()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pa.Invoke(i))
+	}
+	require.Eventually(t, func() bool { return pa.Stats().Completed == 3 }, time.Second, time.Millisecond)
+	require.GreaterOrEqual(t, pa.Stats().Retired, int64(1))
+}
+
+func TestPoolWithFuncGenericQueuedNilInterface(t *testing.T) {
+	block := make(chan struct{})
+	var got atomic.Int32
+	p, err := NewPoolWithFuncGeneric(1, func(err error) {
+		if err == nil {
+			got.Add(1)
+			return
+		}
+		<-block
+	}, WithTaskQueue(4))
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.NoError(t, p.Invoke(errors.New("block")))
+	require.NoError(t, p.Invoke(nil))
+	require.Equal(t, 1, p.Queued())
+	close(block)
+
+	require.Eventually(t, func() bool { return got.Load() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Invoke(nil))
+	require.Eventually(t, func() bool { return got.Load() == 2 }, time.Second, time.Millisecond)
+	require.EqualValues(t, 0, p.Stats().Panicked)
+}
@@ -0,0 +1,122 @@
+package pppool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrPoolNameTaken = errors.New("pool name has been registered")
+
+// Instance is the part of a pool's API shared by Pool, PoolWithFunc and PoolWithFuncGeneric,
+// pools are registered and looked up by name through it.
+type Instance interface {
+	Name() string
+	Stats() Stats
+	Options() Options
+	Running() int
+	Free() int
+	Waiting() int
+	Cap() int
+	Tune(size int) error
+	Pause()
+	Resume()
+	IsPaused() bool
+	IsClosed() bool
+	Purge()
+	Release()
+
+	common() *poolCommon
+}
+
+var registry = struct {
+	sync.RWMutex
+	pools map[string]Instance
+}{pools: make(map[string]Instance)}
+
+func (p *poolCommon) common() *poolCommon {
+	return p
+}
+
+// Name returns the name the pool is registered under, or "" if it's not registered.
+func (p *poolCommon) Name() string {
+	name, _ := p.name.Load().(string)
+	return name
+}
+
+// Register adds p to the process wide registry under name, the pool is removed
+// again by Unregister or when it's released. Log lines of the pool are prefixed with name
+// while it's registered, a rebooted pool has to be registered again.
+func Register(name string, p Instance) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if old, ok := registry.pools[name]; ok && old.common() != p.common() {
+		return ErrPoolNameTaken
+	}
+	if prev := p.Name(); prev != "" && prev != name {
+		if old, ok := registry.pools[prev]; ok && old.common() == p.common() {
+			delete(registry.pools, prev)
+		}
+	}
+	registry.pools[name] = p
+	p.common().name.Store(name)
+	return nil
+}
+
+func Unregister(name string) {
+	registry.Lock()
+	if p, ok := registry.pools[name]; ok {
+		delete(registry.pools, name)
+		p.common().name.Store("")
+	}
+	registry.Unlock()
+}
+
+func Lookup(name string) (Instance, bool) {
+	registry.RLock()
+	p, ok := registry.pools[name]
+	registry.RUnlock()
+	return p, ok
+}
+
+// Range calls fn for every registered pool in name order until fn returns false.
+// fn is called without holding the registry lock, so it may register or release pools.
+func Range(fn func(name string, p Instance) bool) {
+	registry.RLock()
+	names := make([]string, 0, len(registry.pools))
+	for name := range registry.pools {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if p, ok := Lookup(name); ok && !fn(name, p) {
+			return
+		}
+	}
+}
+
+// unregister removes p from the registry if it's still registered under its name.
+func (p *poolCommon) unregister() {
+	registry.Lock()
+	defer registry.Unlock()
+	name := p.Name()
+	if name == "" {
+		return
+	}
+	if old, ok := registry.pools[name]; ok && old.common() == p {
+		delete(registry.pools, name)
+	}
+	p.name.Store("")
+}
+
+// logf writes to options.Logger, prefixed with the pool name when the pool has one.
+func (p *poolCommon) logf(format string, args ...any) {
+	if name := p.Name(); name != "" {
+		format = "[" + name + "] " + format
+	}
+	p.options.Logger.Printf(format, args...)
+}
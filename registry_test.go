@@ -0,0 +1,82 @@
+package pppool
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	p, err := NewPool(10)
+	require.NoError(t, err)
+	pf, err := NewPoolWithFuncGeneric(10, func(int) {})
+	require.NoError(t, err)
+	defer pf.Release()
+
+	require.NoError(t, Register("registry-a", p))
+	require.NoError(t, Register("registry-b", pf))
+	require.ErrorIs(t, Register("registry-a", pf), ErrPoolNameTaken)
+	require.Equal(t, "registry-a", p.Name())
+
+	got, ok := Lookup("registry-a")
+	require.True(t, ok)
+	require.Equal(t, 10, got.Cap())
+
+	var names []string
+	Range(func(name string, _ Instance) bool {
+		names = append(names, name)
+		return true
+	})
+	require.Subset(t, names, []string{"registry-a", "registry-b"})
+
+	p.Release()
+	_, ok = Lookup("registry-a")
+	require.False(t, ok)
+	require.ErrorIs(t, Register("registry-a", p), ErrorPoolClosed)
+
+	Unregister("registry-b")
+	_, ok = Lookup("registry-b")
+	require.False(t, ok)
+}
+
+func TestRegistryLogPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewPool(1, WithLogger(log.New(&buf, "", 0)))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, Register("registry-log", p))
+
+	p.logf("hello %d\n", 1)
+	require.Equal(t, "[registry-log] hello 1\n", buf.String())
+}
+
+func TestRegistryReuseName(t *testing.T) {
+	a, err := NewPool(1)
+	require.NoError(t, err)
+	defer a.Release()
+	b, err := NewPool(1)
+	require.NoError(t, err)
+	defer b.Release()
+
+	require.NoError(t, Register("registry-x", a))
+	Unregister("registry-x")
+	require.Equal(t, "", a.Name())
+	require.NoError(t, Register("registry-x", b))
+
+	require.NoError(t, Register("registry-y", a))
+	got, ok := Lookup("registry-x")
+	require.True(t, ok)
+	require.Equal(t, "registry-x", got.Name())
+	require.Equal(t, "registry-x", b.Name())
+	Unregister("registry-y")
+
+	require.NoError(t, b.ReleaseTimeout(time.Second))
+	require.Equal(t, "", b.Name())
+	require.NoError(t, b.Reboot())
+	_, ok = Lookup("registry-x")
+	require.False(t, ok)
+	require.Equal(t, "", b.Name())
+}
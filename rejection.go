@@ -0,0 +1,57 @@
+package pppool
+
+// RejectionPolicy decides what happens to a task the pool can not accept, i.e. when
+// retrieveWorker fails with ErrPoolOverload under Nonblocking or MaxBlockingTasks.
+type RejectionPolicy int
+
+const (
+	// AbortPolicy returns ErrPoolOverload to the submitter, it's the default policy.
+	AbortPolicy RejectionPolicy = iota
+
+	// CallerRunsPolicy runs the task on the submitting goroutine.
+	CallerRunsPolicy
+
+	// DiscardPolicy drops the task silently, the number of dropped tasks is reported by Discarded.
+	DiscardPolicy
+
+	// CallbackPolicy hands the task to Options.RejectionHandler.
+	CallbackPolicy
+
+	// DiscardOldestPolicy drops the oldest task in the task queue to make room for the new one
+	// (see retrieveWorker), it behaves like DiscardPolicy if the task queue is not enabled.
+	DiscardOldestPolicy
+)
+
+// reject applies the rejection policy to a task, onDiscard (which may be nil) is called
+// with ErrPoolOverload if the task is dropped.
+func (p *poolCommon) reject(task func(), onDiscard func(err error)) error {
+	switch p.options.RejectionPolicy {
+	case CallerRunsPolicy:
+		task()
+		return nil
+	case DiscardPolicy, DiscardOldestPolicy:
+		p.discarded.Add(1)
+		if onDiscard != nil {
+			onDiscard(ErrPoolOverload)
+		}
+		return nil
+	case CallbackPolicy:
+		if h := p.options.RejectionHandler; h != nil {
+			h(task)
+			return nil
+		}
+	}
+	return ErrPoolOverload
+}
+
+// discardableTask is how a task with a discard hook waits in the task queue, so that
+// DiscardOldestPolicy can tell its submitter that it will never run, see Pool.submit.
+type discardableTask struct {
+	run       func()
+	onDiscard func(err error)
+}
+
+// Discarded returns the number of tasks dropped by DiscardPolicy or DiscardOldestPolicy.
+func (p *poolCommon) Discarded() int {
+	return int(p.discarded.Load())
+}
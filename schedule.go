@@ -0,0 +1,102 @@
+package pppool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// scheduler holds the delayed and periodic tasks of a pool. Its timing wheel is advanced by
+// the ticktock goroutine, and the expired tasks are handed to a dispatcher goroutine which
+// submits them, so that a full pool never blocks the clock.
+type scheduler struct {
+	wheel *timingWheel
+
+	submit func(task func()) error
+
+	mu    sync.Mutex
+	ready []*wheelTimer
+	kick  chan struct{}
+
+	resets atomic.Int64 //reset的次数，Cron据此发现自己的timer已经被Release清掉了
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		wheel: newTimingWheel(wheelTickInterval),
+		kick:  make(chan struct{}, 1),
+	}
+}
+
+func (s *scheduler) push(timers []*wheelTimer) {
+	s.mu.Lock()
+	s.ready = append(s.ready, timers...)
+	s.mu.Unlock()
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (s *scheduler) take() []*wheelTimer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timers := s.ready
+	s.ready = nil
+	return timers
+}
+
+func (s *scheduler) reset() {
+	s.wheel.reset()
+	s.take()
+	s.resets.Add(1)
+}
+
+func (p *poolCommon) dispatchScheduled(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.scheduler.kick:
+		}
+		for _, t := range p.scheduler.take() {
+			if ctx.Err() != nil { //pool已经Release，剩下的任务属于上一轮，不能在Reboot之后提交
+				return
+			}
+			if t.expire != nil && !t.expire() {
+				continue
+			}
+			if err := p.scheduler.submit(t.task); err != nil && err != ErrorPoolClosed {
+				p.logf("scheduled task dropped: %v\n", err)
+			}
+		}
+	}
+}
+
+func (p *Pool) SubmitAfter(d time.Duration, task func()) error {
+	return p.SubmitAt(time.Now().Add(d), task)
+}
+
+// SubmitAt submits the task to the pool at t, it's subject to the blocking and rejection
+// behavior of the pool like any other task once it fires.
+func (p *Pool) SubmitAt(t time.Time, task func()) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	p.scheduler.wheel.add(&wheelTimer{task: task, expiration: t})
+	return nil
+}
+
+// Schedule submits the task to the pool every interval until cancel is called or
+// the pool is released. A non-positive interval schedules nothing.
+func (p *Pool) Schedule(interval time.Duration, task func()) (cancel func()) {
+	if p.IsClosed() || interval <= 0 {
+		return func() {}
+	}
+	t := &wheelTimer{task: task, expiration: time.Now().Add(interval), interval: interval}
+	p.scheduler.wheel.add(t)
+	return func() {
+		p.scheduler.wheel.remove(t)
+	}
+}
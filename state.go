@@ -0,0 +1,64 @@
+package pppool
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrLackWorkerState = errors.New("must set up worker state with WithWorkerState")
+
+// stateTask is how a SubmitWithState task waits in the task queue, the worker that
+// polls it binds its own state.
+type stateTask func(state any)
+
+// SubmitWithState works like Submit, but task gets the state of the worker running it,
+// which is created by the init func of WithWorkerState on the first such task of a worker
+// and closed when the worker exits. If the task ends up on the submitter under
+// CallerRunsPolicy, a state is created and closed just for it.
+func (p *Pool) SubmitWithState(task func(state any)) error {
+	if p.options.WorkerStateInit == nil {
+		return ErrLackWorkerState
+	}
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	w, err := p.retrieveWorker(context.Background(), stateTask(task), 0)
+	if w != nil {
+		gw := w.(*goWorker)
+		w.inputFunc(func() { task(gw.workerState()) })
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(func() {
+			state := p.options.WorkerStateInit()
+			defer p.closeState(state)
+			task(state)
+		}, nil)
+	}
+	return err
+}
+
+func (p *poolCommon) closeState(state any) {
+	if c := p.options.WorkerStateClose; c != nil {
+		c(state)
+	}
+}
+
+// workerState is only called on the worker goroutine.
+func (w *goWorker) workerState() any {
+	if !w.hasState {
+		w.state = w.pool.options.WorkerStateInit()
+		w.hasState = true
+	}
+	return w.state
+}
+
+// closeState releases the state before the worker goes back to workerCache.
+func (w *goWorker) closeState() {
+	if !w.hasState {
+		return
+	}
+	state := w.state
+	w.state, w.hasState = nil, false
+	w.pool.closeState(state)
+}
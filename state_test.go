@@ -0,0 +1,46 @@
+package pppool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitWithState(t *testing.T) {
+	var inits, closes, total atomic.Int32
+	p, err := NewPool(2, WithTaskQueue(16), WithWorkerState(
+		func() any {
+			inits.Add(1)
+			return new(int)
+		},
+		func(state any) {
+			closes.Add(1)
+			total.Add(int32(*state.(*int)))
+		},
+	))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		require.NoError(t, p.SubmitWithState(func(state any) {
+			defer wg.Done()
+			*state.(*int)++ //只有所属的worker会访问自己的state
+			time.Sleep(time.Millisecond)
+		}))
+	}
+	wg.Wait()
+	require.LessOrEqual(t, inits.Load(), int32(2))
+
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	require.Equal(t, inits.Load(), closes.Load())
+	require.EqualValues(t, 20, total.Load())
+
+	pool, err := NewPool(1)
+	require.NoError(t, err)
+	defer pool.Release()
+	require.ErrorIs(t, pool.SubmitWithState(func(any) {}), ErrLackWorkerState)
+}
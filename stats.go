@@ -0,0 +1,65 @@
+package pppool
+
+import "time"
+
+// Stats is a snapshot of the state and the cumulative counters of a pool.
+type Stats struct {
+	Capacity int `json:"capacity"`
+	Running  int `json:"running"`
+	Idle     int `json:"idle"`
+	Waiting  int `json:"waiting"`
+	Queued   int `json:"queued"`
+
+	// Submitted counts the tasks accepted by the pool, either handed to a worker or queued.
+	Submitted int64 `json:"submitted"`
+	Completed int64 `json:"completed"`
+	Panicked  int64 `json:"panicked"`
+
+	// Rejected counts the times the pool was overloaded, before the rejection policy applies.
+	Rejected  int64 `json:"rejected"`
+	Discarded int64 `json:"discarded"`
+	Abandoned int64 `json:"abandoned"`
+
+	// Spawned counts the worker goroutines started, Purged the idle workers reaped by the purger
+	// and Retired the workers which exited after WorkerMaxTasks or WorkerMaxAge.
+	Spawned int64 `json:"spawned"`
+	Purged  int64 `json:"purged"`
+	Retired int64 `json:"retired"`
+
+	// Adjustments counts the capacity changes made by the adaptive controller.
+	Adjustments int64 `json:"adjustments"`
+
+	// WaitTime is the cumulative time callers spent blocked waiting for a worker.
+	WaitTime time.Duration `json:"wait_time"`
+
+	// WaitLatency is the time from Submit to the start of the task, ExecLatency the running time of the task.
+	WaitLatency HistogramSnapshot `json:"wait_latency"`
+	ExecLatency HistogramSnapshot `json:"exec_latency"`
+}
+
+func (p *poolCommon) Stats() Stats {
+	p.lock.Lock()
+	s := Stats{
+		Capacity: p.Cap(),
+		Running:  p.Running(),
+		Idle:     p.workers.len(),
+		Waiting:  p.Waiting(),
+		Queued:   p.Queued(),
+	}
+	p.lock.Unlock()
+
+	s.Submitted = p.submitted.Load()
+	s.Completed = p.completed.Load()
+	s.Panicked = p.panicked.Load()
+	s.Rejected = p.rejected.Load()
+	s.Discarded = p.discarded.Load()
+	s.Abandoned = p.abandoned.Load()
+	s.Spawned = p.spawned.Load()
+	s.Purged = p.purged.Load()
+	s.Retired = p.retired.Load()
+	s.Adjustments = p.adjustments.Load()
+	s.WaitTime = time.Duration(p.waitTime.Load())
+	s.WaitLatency = p.waitLatency.snapshot()
+	s.ExecLatency = p.execLatency.snapshot()
+	return s
+}
@@ -0,0 +1,86 @@
+package pppool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultTaskTimeoutGrace = time.Second
+
+const (
+	taskRunning int32 = iota
+	taskDone
+	taskAbandoned
+)
+
+// SubmitWithTimeout submits a task whose context is cancelled after timeout. If the task has
+// not returned within Options.TaskTimeoutGrace after that, it is abandoned: its worker no
+// longer counts against the capacity of the pool, and OnTaskTimeout is called. A task run
+// by the rejection policy instead of a worker is reported the same way, but holds no capacity.
+func (p *Pool) SubmitWithTimeout(timeout time.Duration, task func(ctx context.Context)) error {
+	if p.IsClosed() {
+		return ErrorPoolClosed
+	}
+	run := func() {
+		p.runWithTimeout(timeout, task, true)
+	}
+	w, err := p.retrieveWorker(context.Background(), run, 0)
+	if w != nil {
+		w.inputFunc(run)
+		return nil
+	}
+	if err == ErrPoolOverload {
+		return p.reject(func() {
+			p.runWithTimeout(timeout, task, false)
+		}, nil)
+	}
+	return err
+}
+
+// runWithTimeout runs task with its deadline, onWorker tells whether it runs on a worker
+// of the pool, only then the capacity is given back when the task is abandoned.
+func (p *poolCommon) runWithTimeout(timeout time.Duration, task func(ctx context.Context), onWorker bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var state atomic.Int32
+	watchdog := time.AfterFunc(timeout+p.options.TaskTimeoutGrace, func() {
+		if state.CompareAndSwap(taskRunning, taskAbandoned) {
+			p.abandonTask(timeout, onWorker)
+		}
+	})
+	defer func() {
+		watchdog.Stop()
+		if !state.CompareAndSwap(taskRunning, taskDone) && onWorker {
+			//任务被放弃后才返回，重新计入running，由revertWorker决定worker是否超出容量需要退出
+			p.addRunning(1)
+		}
+	}()
+	task(ctx)
+}
+
+// abandonTask reports a hung task by Abandoned and OnTaskTimeout, the goroutine itself can not
+// be stopped. If the task runs on a worker, the capacity held by it is given back to the pool
+// and goes to the queued tasks first.
+func (p *poolCommon) abandonTask(timeout time.Duration, onWorker bool) {
+	p.abandoned.Add(1)
+	if onWorker {
+		p.workerDone()
+		p.drainQueue()
+		p.lock.Lock()
+		p.cond.Signal()
+		p.lock.Unlock()
+	}
+
+	if h := p.options.OnTaskTimeout; h != nil {
+		h(timeout)
+	} else {
+		p.logf("task abandoned after timeout %v\n", timeout)
+	}
+}
+
+// Abandoned returns the number of tasks abandoned by SubmitWithTimeout.
+func (p *poolCommon) Abandoned() int {
+	return int(p.abandoned.Load())
+}
@@ -0,0 +1,146 @@
+package pppool
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	wheelTickInterval = 10 * time.Millisecond
+	wheelSize         = 512
+)
+
+type wheelTimer struct {
+	task   func()
+	expire func() bool //不为nil时dispatcher在提交task之前调用，返回false则不提交
+
+	expiration time.Time
+
+	interval time.Duration //大于0表示周期任务
+
+	rounds int //还要转几圈才到期
+	slot   int
+	elem   *list.Element
+}
+
+// timingWheel is a single level hashed timing wheel, timers further than one round away
+// stay in their slot and count down their rounds. It does not own a clock, the owner
+// advances it with the current time.
+type timingWheel struct {
+	mu sync.Mutex
+
+	tick  time.Duration
+	slots []list.List //第一次添加timer时才分配
+
+	pos     int
+	current time.Time //slots[pos]对应的时间
+	pending int
+
+	wakeup chan struct{} //wheel由空变为非空时通知owner
+}
+
+func newTimingWheel(tick time.Duration) *timingWheel {
+	return &timingWheel{
+		tick:   tick,
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+func (tw *timingWheel) len() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.pending
+}
+
+func (tw *timingWheel) add(t *wheelTimer) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.slots == nil {
+		tw.slots = make([]list.List, wheelSize)
+	}
+	if tw.pending == 0 {
+		//wheel为空时owner不会按tick推进，current可能已经过时了
+		tw.current = time.Now()
+		select {
+		case tw.wakeup <- struct{}{}:
+		default:
+		}
+	}
+	tw.addLocked(t)
+}
+
+func (tw *timingWheel) addLocked(t *wheelTimer) {
+	ticks := int((t.expiration.Sub(tw.current) + tw.tick - 1) / tw.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+	t.slot = (tw.pos + ticks) % len(tw.slots)
+	t.rounds = (ticks - 1) / len(tw.slots)
+	t.elem = tw.slots[t.slot].PushBack(t)
+	tw.pending++
+}
+
+func (tw *timingWheel) remove(t *wheelTimer) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if t.elem != nil {
+		tw.slots[t.slot].Remove(t.elem)
+		t.elem = nil
+		tw.pending--
+	}
+}
+
+// advance moves the wheel forward to now and returns the expired timers, periodic timers
+// are put back for their next expiration.
+func (tw *timingWheel) advance(now time.Time) []*wheelTimer {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	var (
+		expired  []*wheelTimer
+		periodic []*wheelTimer
+	)
+	for tw.pending > 0 && !tw.current.Add(tw.tick).After(now) {
+		tw.current = tw.current.Add(tw.tick)
+		tw.pos = (tw.pos + 1) % len(tw.slots)
+		l := &tw.slots[tw.pos]
+		for e := l.Front(); e != nil; {
+			next := e.Next()
+			t := e.Value.(*wheelTimer)
+			if t.rounds > 0 {
+				t.rounds--
+			} else {
+				l.Remove(e)
+				t.elem = nil
+				tw.pending--
+				expired = append(expired, t)
+				if t.interval > 0 {
+					periodic = append(periodic, t)
+				}
+			}
+			e = next
+		}
+		//处理完这个slot再放回去，避免间隔正好是一圈的timer又落回当前slot被重复触发
+		for _, t := range periodic {
+			for t.expiration = t.expiration.Add(t.interval); !t.expiration.After(tw.current); {
+				t.expiration = t.expiration.Add(t.interval)
+			}
+			tw.addLocked(t)
+		}
+		periodic = periodic[:0]
+	}
+	return expired
+}
+
+func (tw *timingWheel) reset() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for i := range tw.slots {
+		for e := tw.slots[i].Front(); e != nil; e = e.Next() {
+			e.Value.(*wheelTimer).elem = nil
+		}
+		tw.slots[i].Init()
+	}
+	tw.pending = 0
+}
@@ -0,0 +1,75 @@
+package pppool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingWheelAdvance(t *testing.T) {
+	tw := newTimingWheel(time.Millisecond)
+	fired := make([]int, 0)
+	newTask := func(i int) func() {
+		return func() { fired = append(fired, i) }
+	}
+
+	tw.add(&wheelTimer{task: newTask(1), expiration: time.Now().Add(5 * time.Millisecond)})
+	start := tw.current
+	tw.add(&wheelTimer{task: newTask(2), expiration: start.Add(2 * time.Millisecond)})
+	//超过一圈的timer
+	tw.add(&wheelTimer{task: newTask(3), expiration: start.Add(wheelSize*time.Millisecond + 3*time.Millisecond)})
+	require.EqualValues(t, 3, tw.len())
+
+	for _, timer := range tw.advance(start.Add(time.Millisecond)) {
+		timer.task()
+	}
+	require.Empty(t, fired)
+
+	for _, timer := range tw.advance(start.Add(10 * time.Millisecond)) {
+		timer.task()
+	}
+	require.Equal(t, []int{2, 1}, fired)
+	require.EqualValues(t, 1, tw.len())
+
+	for _, timer := range tw.advance(start.Add(wheelSize*time.Millisecond + 2*time.Millisecond)) {
+		timer.task()
+	}
+	require.Equal(t, []int{2, 1}, fired)
+	for _, timer := range tw.advance(start.Add(wheelSize*time.Millisecond + 3*time.Millisecond)) {
+		timer.task()
+	}
+	require.Equal(t, []int{2, 1, 3}, fired)
+	require.EqualValues(t, 0, tw.len())
+}
+
+func TestTimingWheelPeriodic(t *testing.T) {
+	tw := newTimingWheel(time.Millisecond)
+	count := 0
+	now := time.Now()
+	timer := &wheelTimer{task: func() { count++ }, expiration: now.Add(wheelSize * time.Millisecond), interval: wheelSize * time.Millisecond}
+	tw.add(timer)
+	start := tw.current
+
+	for i := 1; i <= 3; i++ {
+		for _, timer := range tw.advance(start.Add(time.Duration(i*wheelSize) * time.Millisecond)) {
+			timer.task()
+		}
+		require.EqualValues(t, i, count)
+		require.EqualValues(t, 1, tw.len())
+	}
+
+	tw.remove(timer)
+	require.EqualValues(t, 0, tw.len())
+	require.Empty(t, tw.advance(start.Add(10*wheelSize*time.Millisecond)))
+}
+
+func TestTimingWheelReset(t *testing.T) {
+	tw := newTimingWheel(time.Millisecond)
+	timer := &wheelTimer{task: func() {}, expiration: time.Now().Add(time.Second)}
+	tw.add(timer)
+	tw.reset()
+	require.EqualValues(t, 0, tw.len())
+	tw.remove(timer)
+	require.EqualValues(t, 0, tw.len())
+}
@@ -10,6 +10,8 @@ type worker interface {
 	finish()
 	lastUsedTime() time.Time
 	setLastUsedTime(time.Time)
+	setSubmitTime(time.Time)
+	submitTime() time.Time
 	inputFunc(func())
 	inputArg(any)
 }
@@ -22,39 +24,22 @@ type goWorker struct {
 	task chan func()
 
 	lastUsed time.Time
+
+	submitted time.Time //当前任务的提交时间，由提交者在交给worker之前设置
+
+	state    any //worker本地状态，见WithWorkerState，只在worker协程上访问
+	hasState bool
 }
 
 func (w *goWorker) run() {
 	w.pool.addRunning(1)
-	go func() {
-		defer func() {
-			if w.pool.addRunning(-1) == 0 && w.pool.IsClosed() {
-				w.pool.once.Do(func() {
-					close(w.pool.allDone)
-				})
-			}
-			w.pool.workerCache.Put(w)
-			if p := recover(); p != nil {
-				if ph := w.pool.options.PanicHandler; ph != nil {
-					ph(p)
-				} else {
-					w.pool.options.Logger.Printf("worker exits from panic: %v\n%s\n", p, debug.Stack())
-				}
-			}
-			//cal signal() here in case there are goroutines waiting for avaliable workers
-			w.pool.cond.Signal()
-		}()
+	go runWorker(w.pool.poolCommon, w, w.next, func(fn func()) { fn() })
+}
 
-		for fn := range w.task { //阻塞等待人物
-			if fn == nil { //finish
-				return
-			}
-			fn()
-			if ok := w.pool.revertWorker(w); !ok { //将worker放入pool的worker queue中，
-				return
-			}
-		}
-	}()
+// next blocks for the next task, a nil task tells the worker to finish.
+func (w *goWorker) next() (func(), bool) {
+	fn := <-w.task
+	return fn, fn != nil
 }
 
 func (w *goWorker) finish() {
@@ -73,6 +58,175 @@ func (w *goWorker) setLastUsedTime(t time.Time) {
 	}
 }
 
+func (w *goWorker) setSubmitTime(t time.Time) {
+	w.submitted = t
+}
+
+func (w *goWorker) submitTime() time.Time {
+	return w.submitted
+}
+
 func (w *goWorker) inputFunc(fn func()) {
 	w.task <- fn
 }
+
+// inputArg receives the tasks coming from the task queue, which are plain funcs,
+// stateTasks or discardableTasks for Pool.
+func (w *goWorker) inputArg(arg any) {
+	switch task := arg.(type) {
+	case func():
+		w.task <- task
+	case stateTask:
+		w.task <- func() { task(w.workerState()) }
+	case discardableTask:
+		w.task <- task.run
+	}
+}
+
+// runWorker is the body of every worker goroutine. next blocks for the next task of w
+// and returns false when w should finish, run executes the task.
+func runWorker[A any](p *poolCommon, w worker, next func() (A, bool), run func(A)) {
+	var start time.Time //正在执行的任务的开始时间，没有任务在执行时为零值，任务panic时交给exitWorker
+	born, done := time.Now(), 0
+	defer func() {
+		p.exitWorker(w, start, recover())
+	}()
+	p.startWorker()
+
+	for {
+		arg, ok := next()
+		if !ok {
+			return
+		}
+		start = p.beforeTask(w.submitTime())
+		run(arg)
+		began := start
+		start = time.Time{}
+		p.afterTask(began)
+		if done++; p.shouldRetire(done, born) {
+			p.retireWorker()
+			return
+		}
+		if ok := p.revertWorker(w); !ok { //将worker放入pool的worker queue中
+			return
+		}
+	}
+}
+
+func (p *poolCommon) startWorker() {
+	if h := p.options.OnWorkerStart; h != nil {
+		p.callHook(h)
+	}
+}
+
+func (p *poolCommon) beforeTask(submitted time.Time) time.Time {
+	if h := p.options.BeforeTask; h != nil {
+		p.callHook(h)
+	}
+	start := time.Now()
+	p.waitLatency.observe(start.Sub(submitted))
+	return start
+}
+
+func (p *poolCommon) afterTask(start time.Time) {
+	d := time.Since(start)
+	p.execLatency.observe(d)
+	p.completed.Add(1)
+	if h := p.options.AfterTask; h != nil {
+		h(d, false)
+	}
+}
+
+// callHook calls a hook which runs before a task, a panic in it goes to PanicHandler
+// instead of taking down the worker together with the task it's about to run.
+func (p *poolCommon) callHook(h func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ph := p.options.PanicHandler; ph != nil {
+				ph(r)
+			} else {
+				p.logf("worker hook panics: %v\n%s\n", r, debug.Stack())
+			}
+		}
+	}()
+	h()
+}
+
+// shouldRetire reports whether a worker which has run done tasks since born has reached
+// WorkerMaxTasks or WorkerMaxAge.
+func (p *poolCommon) shouldRetire(done int, born time.Time) bool {
+	if n := p.options.WorkerMaxTasks; n > 0 && done >= n {
+		return true
+	}
+	if d := p.options.WorkerMaxAge; d > 0 && time.Since(born) >= d {
+		return true
+	}
+	return false
+}
+
+// retireWorker is called by a worker which exits instead of going back through revertWorker.
+// Blocked callers are woken up by exitWorker and spawn new workers, but queued tasks are
+// only polled by reverting workers, so a fresh worker takes over the oldest one.
+func (p *poolCommon) retireWorker() {
+	p.retired.Add(1)
+	if p.tasks == nil {
+		return
+	}
+	p.lock.Lock()
+	if len(p.tasks) > 0 {
+		w := p.workerCache.Get().(worker)
+		w.run()
+		p.spawned.Add(1)
+		p.pollTask(w)
+	}
+	p.lock.Unlock()
+}
+
+// drainQueue is called when capacity is given back by a worker which does not go through
+// revertWorker, it spawns new workers for the queued tasks while the capacity allows.
+func (p *poolCommon) drainQueue() {
+	if p.tasks == nil {
+		return
+	}
+	p.lock.Lock()
+	for len(p.tasks) > 0 && !p.IsClosed() {
+		if capacity := p.Cap(); capacity != -1 && capacity <= p.Running() {
+			break
+		}
+		w := p.workerCache.Get().(worker)
+		w.run()
+		p.spawned.Add(1)
+		p.pollTask(w)
+	}
+	p.lock.Unlock()
+}
+
+// exitWorker is deferred by every worker goroutine, r is the value recovered from a panic and
+// start is when the task running at that time started, zero if the panic was not in a task.
+func (p *poolCommon) exitWorker(w worker, start time.Time, r any) {
+	if gw, ok := w.(*goWorker); ok {
+		gw.closeState()
+	}
+	inTask := r != nil && !start.IsZero()
+	if h := p.options.AfterTask; h != nil && inTask {
+		h(time.Since(start), true)
+	}
+	if h := p.options.OnWorkerExit; h != nil {
+		h()
+	}
+	p.workerDone()
+	p.workerCache.Put(w)
+	if r != nil {
+		p.drainQueue()
+		if inTask {
+			p.panicked.Add(1)
+		}
+		if ph := p.options.PanicHandler; ph != nil {
+			ph(r)
+		} else {
+			p.logf("worker exits from panic: %v\n%s\n", r, debug.Stack())
+		}
+	}
+	//cal signal() here in case there are goroutines waiting for avaliable workers
+	p.cond.Signal()
+}
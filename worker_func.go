@@ -0,0 +1,57 @@
+package pppool
+
+import "time"
+
+type goWorkerWithFunc struct {
+	worker
+
+	pool *PoolWithFunc
+
+	arg chan any
+
+	exit chan struct{} //arg可能就是nil，所以用单独的channel来结束worker
+
+	lastUsed time.Time
+
+	submitted time.Time
+}
+
+func (w *goWorkerWithFunc) run() {
+	w.pool.addRunning(1)
+	go runWorker(w.pool.poolCommon, w, w.next, w.pool.fn)
+}
+
+func (w *goWorkerWithFunc) next() (any, bool) {
+	select {
+	case <-w.exit:
+		return nil, false
+	case arg := <-w.arg:
+		return arg, true
+	}
+}
+
+func (w *goWorkerWithFunc) finish() {
+	w.exit <- struct{}{}
+}
+
+func (w *goWorkerWithFunc) lastUsedTime() time.Time {
+	return w.lastUsed
+}
+
+func (w *goWorkerWithFunc) setLastUsedTime(t time.Time) {
+	if !t.After(time.Now()) {
+		w.lastUsed = t
+	}
+}
+
+func (w *goWorkerWithFunc) setSubmitTime(t time.Time) {
+	w.submitted = t
+}
+
+func (w *goWorkerWithFunc) submitTime() time.Time {
+	return w.submitted
+}
+
+func (w *goWorkerWithFunc) inputArg(arg any) {
+	w.arg <- arg
+}
@@ -0,0 +1,57 @@
+package pppool
+
+import "time"
+
+type goWorkerWithFuncGeneric[T any] struct {
+	worker
+
+	pool *PoolWithFuncGeneric[T]
+
+	arg chan T
+
+	exit chan struct{}
+
+	lastUsed time.Time
+
+	submitted time.Time
+}
+
+func (w *goWorkerWithFuncGeneric[T]) run() {
+	w.pool.addRunning(1)
+	go runWorker(w.pool.poolCommon, w, w.next, w.pool.fn)
+}
+
+func (w *goWorkerWithFuncGeneric[T]) next() (arg T, ok bool) {
+	select {
+	case <-w.exit:
+		return arg, false
+	case arg = <-w.arg:
+		return arg, true
+	}
+}
+
+func (w *goWorkerWithFuncGeneric[T]) finish() {
+	w.exit <- struct{}{}
+}
+
+func (w *goWorkerWithFuncGeneric[T]) lastUsedTime() time.Time {
+	return w.lastUsed
+}
+
+func (w *goWorkerWithFuncGeneric[T]) setLastUsedTime(t time.Time) {
+	if !t.After(time.Now()) {
+		w.lastUsed = t
+	}
+}
+
+func (w *goWorkerWithFuncGeneric[T]) setSubmitTime(t time.Time) {
+	w.submitted = t
+}
+
+func (w *goWorkerWithFuncGeneric[T]) submitTime() time.Time {
+	return w.submitted
+}
+
+func (w *goWorkerWithFuncGeneric[T]) inputArg(arg any) {
+	w.arg <- arg.(genericArg[T]).v
+}
@@ -17,7 +17,7 @@ func TestNewWorkerLoopQueue(t *testing.T) {
 	require.Equal(t, 0, q.len())
 
 	for i := 0; i < 10; i++ {
-		q.insert(&goWorker{lastUsed: time.Now()})
+		q.insert(&goWorker{lastUsed: time.Now(), task: make(chan func(), 1)})
 	}
 	require.Equal(t, false, q.isEmpty())
 	require.EqualValues(t, 10, q.len())
@@ -25,7 +25,7 @@ func TestNewWorkerLoopQueue(t *testing.T) {
 	require.EqualValues(t, q.head, q.tail)
 	require.Equal(t, true, q.isFull)
 
-	err := q.insert(&goWorker{lastUsed: time.Now()})
+	err := q.insert(&goWorker{lastUsed: time.Now(), task: make(chan func(), 1)})
 	require.NotNil(t, err, "queue is full, return should be error")
 	require.Equal(t, err.Error(), ErrQueueIsFull.Error())
 
@@ -39,7 +39,7 @@ func TestNewWorkerLoopQueue(t *testing.T) {
 
 	//tail < head的情况     测试tail是否能够正常循环
 	for i := 0; i < 4; i++ {
-		q.insert(&goWorker{lastUsed: time.Now()})
+		q.insert(&goWorker{lastUsed: time.Now(), task: make(chan func(), 1)})
 	}
 	require.Equal(t, false, q.isFull)
 	require.Equal(t, false, q.isEmpty())
@@ -47,7 +47,7 @@ func TestNewWorkerLoopQueue(t *testing.T) {
 	require.EqualValues(t, 5, q.head)
 	require.EqualValues(t, 4, q.tail)
 
-	q.insert(&goWorker{lastUsed: time.Now()})
+	q.insert(&goWorker{lastUsed: time.Now(), task: make(chan func(), 1)})
 	require.Equal(t, true, q.isFull)
 	require.Equal(t, false, q.isEmpty())
 	require.EqualValues(t, 10, q.len())
@@ -54,9 +54,10 @@ func TestWorkerQueueStack(t *testing.T) {
 	}
 
 	require.EqualValues(t, 104, q.len(), "workerQueue error")
-	q.refresh(time.Second)
+	expiry := q.refresh(time.Second)
 
 	require.EqualValues(t, 100, q.len(), "workerQueue error")
+	require.EqualValues(t, 4, len(expiry), "all stale workers should be returned")
 }
 
 func TestExample(t *testing.T) {
@@ -49,7 +49,7 @@ func (ws *workerStack) refresh(duration time.Duration) []worker {
 	index := ws.binarySearch(expiryTime)
 	ws.expiry = ws.expiry[:0] //对一个空切片（nil）截取，是不会报错的
 	if index != -1 {
-		ws.expiry = append(ws.expiry, ws.items[:index]...)
+		ws.expiry = append(ws.expiry, ws.items[:index+1]...)
 		m := copy(ws.items, ws.items[index+1:])
 		for i := m; i < n; i++ {
 			ws.items[i] = nil